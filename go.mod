@@ -3,10 +3,12 @@ module github.com/PostService
 go 1.15
 
 require (
+	github.com/andybalholm/brotli v1.1.0
 	github.com/go-redis/redis v6.15.9+incompatible
 	github.com/golang/mock v1.5.0
 	github.com/gorilla/handlers v1.5.1
 	github.com/gorilla/mux v1.8.0
 	github.com/sirupsen/logrus v1.8.0
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/text v0.3.5
 )
@@ -0,0 +1,160 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// State is state of breaker
+type State int
+
+// States of breaker
+const (
+	// Closed breaker lets all calls through
+	Closed State = iota
+	// Open breaker rejects calls
+	Open
+	// HalfOpen breaker lets limited amount of probe calls through
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	}
+	return "half-open"
+}
+
+// ErrOpen is returned by Call instead of calling through open breaker
+var ErrOpen = errors.New("circuit breaker is open")
+
+// OpenError is ErrOpen telling when breaker lets calls through again
+type OpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return ErrOpen.Error()
+}
+
+// Unwrap return ErrOpen
+func (e *OpenError) Unwrap() error {
+	return ErrOpen
+}
+
+// Breaker stops calling failing dependency: it opens after threshold consecutive failures,
+// lets probes calls through after open timeout and closes once all of them succeed,
+// failed probe opens it again
+type Breaker struct {
+	mu          sync.Mutex
+	threshold   int
+	openTimeout time.Duration
+	probes      int
+	onChange    func(from, to State)
+	now         func() time.Time
+
+	state    State
+	failures int
+	openedAt time.Time
+	// inFlight and succeeded count probes of half-open breaker
+	inFlight  int
+	succeeded int
+	// generation changes with state, so outcomes of calls allowed in previous state are ignored
+	generation uint64
+}
+
+// New return closed breaker, onChange is called on every state change and may be nil
+func New(threshold int, openTimeout time.Duration, probes int, onChange func(from, to State)) *Breaker {
+	if probes <= 0 {
+		probes = 1
+	}
+	if onChange == nil {
+		onChange = func(from, to State) {}
+	}
+	return &Breaker{threshold: threshold, openTimeout: openTimeout, probes: probes, onChange: onChange, now: time.Now}
+}
+
+// Call calls fn unless breaker is open, failed tells which errors of fn mean that dependency is unhealthy
+func (b *Breaker) Call(fn func() error, failed func(error) bool) error {
+	generation, err := b.allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	b.report(generation, err != nil && failed(err))
+	return err
+}
+
+// State return current state of breaker
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		wait := b.openTimeout - b.now().Sub(b.openedAt)
+		if wait > 0 {
+			return 0, &OpenError{RetryAfter: wait}
+		}
+		b.setState(HalfOpen)
+	}
+	if b.state == HalfOpen {
+		if b.inFlight >= b.probes {
+			// probes are still running, their outcome is known in a moment
+			return 0, &OpenError{RetryAfter: time.Second}
+		}
+		b.inFlight++
+	}
+	return b.generation, nil
+}
+
+func (b *Breaker) report(generation uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case Closed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.setState(Open)
+		}
+	case HalfOpen:
+		b.inFlight--
+		if failed {
+			b.setState(Open)
+			return
+		}
+		b.succeeded++
+		if b.succeeded >= b.probes {
+			b.setState(Closed)
+		}
+	}
+}
+
+// setState moves breaker to state, must be called with mu held
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.failures, b.inFlight, b.succeeded = 0, 0, 0
+	if state == Open {
+		b.openedAt = b.now()
+	}
+	b.onChange(from, state)
+}
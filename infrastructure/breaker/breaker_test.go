@@ -0,0 +1,69 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	changes := []string{}
+	b := New(2, 10*time.Second, 2, func(from, to State) {
+		changes = append(changes, from.String()+" -> "+to.String())
+	})
+	b.now = func() time.Time { return now }
+
+	errDown := errors.New("down")
+	errBusiness := errors.New("not found")
+	failed := func(err error) bool { return err == errDown }
+	call := func(err error) error {
+		return b.Call(func() error { return err }, failed)
+	}
+
+	assert.Equal(t, errDown, call(errDown))
+	assert.Equal(t, errBusiness, call(errBusiness))
+	assert.Equal(t, errDown, call(errDown))
+	assert.Equal(t, Closed, b.State(), "failures are counted in a row")
+	assert.Equal(t, errDown, call(errDown))
+	assert.Equal(t, Open, b.State())
+
+	now = now.Add(4 * time.Second)
+	err := call(nil)
+	assert.True(t, errors.Is(err, ErrOpen))
+	var open *OpenError
+	assert.True(t, errors.As(err, &open))
+	assert.Equal(t, 6*time.Second, open.RetryAfter)
+
+	now = now.Add(6 * time.Second)
+	assert.Equal(t, errDown, call(errDown))
+	assert.Equal(t, Open, b.State(), "failed probe opens breaker again")
+
+	now = now.Add(10 * time.Second)
+	assert.NoError(t, call(nil))
+	assert.Equal(t, HalfOpen, b.State())
+	assert.NoError(t, call(nil))
+	assert.Equal(t, Closed, b.State())
+
+	assert.Equal(t, []string{
+		"closed -> open", "open -> half-open", "half-open -> open",
+		"open -> half-open", "half-open -> closed",
+	}, changes)
+}
+
+func TestProbesLimit(t *testing.T) {
+	b := New(1, 0, 1, nil)
+	failed := func(err error) bool { return true }
+	assert.Error(t, b.Call(func() error { return errors.New("down") }, failed))
+
+	err := b.Call(func() error {
+		// second call while probe is running is rejected
+		inner := b.Call(func() error { return nil }, failed)
+		assert.True(t, errors.Is(inner, ErrOpen))
+		return nil
+	}, failed)
+	assert.NoError(t, err)
+	assert.Equal(t, Closed, b.State())
+}
@@ -0,0 +1,67 @@
+package certs
+
+import (
+	"crypto/x509"
+	"sync"
+	"time"
+
+	"github.com/PostService/infrastructure/logger"
+)
+
+// caPool is pool of CA certificates read from file, which is read again once it changes
+// File is checked when pool is requested, at most once per interval
+type caPool struct {
+	file     string
+	interval time.Duration
+	log      logger.Logger
+	now      func() time.Time
+
+	mu      sync.Mutex
+	pool    *x509.CertPool
+	stamp   stamp
+	checked time.Time
+}
+
+// newCAPool reads CA certificates, file which can not be read or holds no certificates is reported
+func newCAPool(file string, interval time.Duration, log logger.Logger) (*caPool, error) {
+	cp := &caPool{file: file, interval: interval, log: log, now: time.Now}
+	st, err := fileStamp(file)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := certPool(file)
+	if err != nil {
+		return nil, err
+	}
+	cp.pool, cp.stamp, cp.checked = pool, st, cp.now()
+	return cp, nil
+}
+
+// certPool return current pool, changed file is read again
+// Pool which can not be read is logged and the previous one is kept until file changes again
+func (cp *caPool) certPool() *x509.CertPool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	now := cp.now()
+	if now.Sub(cp.checked) < cp.interval {
+		return cp.pool
+	}
+	cp.checked = now
+
+	st, err := fileStamp(cp.file)
+	if err != nil {
+		cp.log.Errorf("can not check CA %s: %v", cp.file, err)
+		return cp.pool
+	}
+	if st == cp.stamp {
+		return cp.pool
+	}
+	pool, err := certPool(cp.file)
+	if err != nil {
+		cp.log.Errorf("can not reload CA %s, previous one is used: %v", cp.file, err)
+		return cp.pool
+	}
+	cp.pool, cp.stamp = pool, st
+	cp.log.Printf("CA %s reloaded", cp.file)
+	return cp.pool
+}
@@ -0,0 +1,116 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/infrastructure/logger"
+)
+
+// defaultReloadInterval is how often changes of certificate files are checked when it is not configured
+const defaultReloadInterval = 10 * time.Second
+
+// ServerConfig return TLS configuration of listener serving certificate which is reloaded
+// once its files change, clients are verified by configured CA which is reloaded once its file
+// changes too, so every handshake gets configuration with the current CA
+func ServerConfig(conf config.TLSConfig, log logger.Logger) (*tls.Config, error) {
+	if conf.CertFile == "" || conf.KeyFile == "" {
+		return nil, errors.New("TLS requires CertFile and KeyFile")
+	}
+	minVersion, err := parseVersion(conf.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	kp, err := newKeyPair(conf.CertFile, conf.KeyFile, reloadInterval(conf.ReloadSeconds), log)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf := &tls.Config{MinVersion: minVersion, GetCertificate: kp.getCertificate}
+
+	if conf.ClientCAFile == "" {
+		if conf.RequireClientCert {
+			return nil, errors.New("TLS RequireClientCert requires ClientCAFile")
+		}
+		return tlsConf, nil
+	}
+	ca, err := newCAPool(conf.ClientCAFile, reloadInterval(conf.ReloadSeconds), log)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf.ClientAuth = tls.VerifyClientCertIfGiven
+	if conf.RequireClientCert {
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	tlsConf.ClientCAs = ca.certPool()
+	base := tlsConf.Clone()
+	tlsConf.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		clientConf := base.Clone()
+		clientConf.ClientCAs = ca.certPool()
+		return clientConf, nil
+	}
+	return tlsConf, nil
+}
+
+// ClientConfig return TLS configuration of redis connections or nil if TLS is not enabled
+// Client certificate is reloaded once its files change, CA is read once, so its changes
+// take effect after restart
+func ClientConfig(conf config.RedisTLSConfig, log logger.Logger) (*tls.Config, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+	minVersion, err := parseVersion(conf.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf := &tls.Config{MinVersion: minVersion, ServerName: conf.ServerName}
+	if conf.CAFile != "" {
+		if tlsConf.RootCAs, err = certPool(conf.CAFile); err != nil {
+			return nil, err
+		}
+	}
+	if conf.CertFile != "" || conf.KeyFile != "" {
+		kp, err := newKeyPair(conf.CertFile, conf.KeyFile, reloadInterval(conf.ReloadSeconds), log)
+		if err != nil {
+			return nil, err
+		}
+		tlsConf.GetClientCertificate = kp.getClientCertificate
+	}
+	return tlsConf, nil
+}
+
+// certPool return pool of PEM encoded certificates of file
+func certPool(file string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return pool, nil
+}
+
+// parseVersion return TLS version of configuration, TLS 1.2 is the oldest one allowed
+func parseVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q", version)
+	}
+}
+
+func reloadInterval(seconds int) time.Duration {
+	if seconds <= 0 {
+		return defaultReloadInterval
+	}
+	return time.Duration(seconds) * time.Second
+}
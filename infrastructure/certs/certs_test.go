@@ -0,0 +1,253 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues certificates of tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// writeCA stores certificate of CA as PEM file
+func (ca *testCA) writeCA(t *testing.T, file string) {
+	writePEM(t, file, "CERTIFICATE", ca.cert.Raw)
+}
+
+// issue stores certificate of localhost with given serial number and its key as PEM files
+func (ca *testCA) issue(t *testing.T, certFile, keyFile string, serial int64) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+}
+
+func writePEM(t *testing.T, file, typ string, der []byte) {
+	require.NoError(t, ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600))
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "certs")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestKeyPairReload(t *testing.T) {
+	dir := tempDir(t)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	ca := newTestCA(t)
+	ca.issue(t, certFile, keyFile, 2)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockLogger := mocks.NewMockLogger(mockCtrl)
+	kp, err := newKeyPair(certFile, keyFile, 10*time.Second, mockLogger)
+	require.NoError(t, err)
+	now := time.Now()
+	kp.now = func() time.Time { return now }
+	serial := func() int64 {
+		cert, err := x509.ParseCertificate(kp.certificate().Certificate[0])
+		require.NoError(t, err)
+		return cert.SerialNumber.Int64()
+	}
+	// replaced certificate is dated later, so change is noticed regardless of timestamp resolution
+	replace := func(serial int64) {
+		ca.issue(t, certFile, keyFile, serial)
+		later := now.Add(time.Duration(serial) * time.Second)
+		require.NoError(t, os.Chtimes(certFile, later, later))
+	}
+
+	replace(1 << 40)
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, int64(2), serial(), "files are not checked before interval passes")
+
+	mockLogger.EXPECT().Printf("certificate %s reloaded", certFile)
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, int64(1<<40), serial())
+
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("broken"), 0600))
+	mockLogger.EXPECT().Errorf("can not reload certificate %s, previous one is used: %v", certFile, gomock.Any())
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, int64(1<<40), serial(), "broken certificate is not used")
+
+	_, err = newKeyPair(certFile, keyFile, time.Second, mockLogger)
+	assert.Error(t, err)
+}
+
+func TestCAPoolReload(t *testing.T) {
+	dir := tempDir(t)
+	caFile := filepath.Join(dir, "ca.pem")
+	oldCA, newCA := newTestCA(t), newTestCA(t)
+	oldCA.writeCA(t, caFile)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockLogger := mocks.NewMockLogger(mockCtrl)
+	cp, err := newCAPool(caFile, 10*time.Second, mockLogger)
+	require.NoError(t, err)
+	now := time.Now()
+	cp.now = func() time.Time { return now }
+	trusts := func(ca *testCA) bool {
+		_, err := ca.cert.Verify(x509.VerifyOptions{Roots: cp.certPool()})
+		return err == nil
+	}
+	// replaced CA is dated later, so change is noticed regardless of timestamp resolution
+	later := now.Add(time.Hour)
+	newCA.writeCA(t, caFile)
+	require.NoError(t, os.Chtimes(caFile, later, later))
+
+	now = now.Add(5 * time.Second)
+	assert.True(t, trusts(oldCA), "file is not checked before interval passes")
+
+	mockLogger.EXPECT().Printf("CA %s reloaded", caFile)
+	now = now.Add(5 * time.Second)
+	assert.True(t, trusts(newCA))
+	assert.False(t, trusts(oldCA))
+
+	require.NoError(t, ioutil.WriteFile(caFile, []byte("broken"), 0600))
+	mockLogger.EXPECT().Errorf("can not reload CA %s, previous one is used: %v", caFile, gomock.Any())
+	now = now.Add(10 * time.Second)
+	assert.True(t, trusts(newCA), "broken CA is not used")
+
+	_, err = newCAPool(caFile, time.Second, mockLogger)
+	assert.EqualError(t, err, "no certificates found in "+caFile)
+}
+
+func TestConfig(t *testing.T) {
+	dir := tempDir(t)
+	caFile := filepath.Join(dir, "ca.pem")
+	serverCert, serverKey := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")
+	clientCert, clientKey := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	ca := newTestCA(t)
+	ca.writeCA(t, caFile)
+	ca.issue(t, serverCert, serverKey, 2)
+	ca.issue(t, clientCert, clientKey, 3)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	log := mocks.NewMockLogger(mockCtrl)
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ServerConfig(config.TLSConfig{CertFile: serverCert}, log)
+		assert.EqualError(t, err, "TLS requires CertFile and KeyFile")
+		_, err = ServerConfig(config.TLSConfig{CertFile: serverCert, KeyFile: serverKey, RequireClientCert: true}, log)
+		assert.EqualError(t, err, "TLS RequireClientCert requires ClientCAFile")
+		_, err = ServerConfig(config.TLSConfig{CertFile: serverCert, KeyFile: serverKey, MinVersion: "1.0"}, log)
+		assert.EqualError(t, err, `unsupported TLS version "1.0"`)
+		_, err = ClientConfig(config.RedisTLSConfig{Enabled: true, CAFile: serverKey}, log)
+		assert.EqualError(t, err, "no certificates found in "+serverKey)
+	})
+
+	t.Run("disabled redis TLS", func(t *testing.T) {
+		conf, err := ClientConfig(config.RedisTLSConfig{CAFile: caFile}, log)
+		assert.NoError(t, err)
+		assert.Nil(t, conf)
+	})
+
+	serverConf, err := ServerConfig(config.TLSConfig{
+		CertFile:          serverCert,
+		KeyFile:           serverKey,
+		MinVersion:        "1.3",
+		ClientCAFile:      caFile,
+		RequireClientCert: true,
+	}, log)
+	require.NoError(t, err)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverConf)
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	handshake := func(conf config.RedisTLSConfig) (tls.ConnectionState, error) {
+		conf.Enabled = true
+		clientConf, err := ClientConfig(conf, log)
+		require.NoError(t, err)
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", ln.Addr().String(), clientConf)
+		if err != nil {
+			return tls.ConnectionState{}, err
+		}
+		defer conn.Close()
+		// client learns about rejected certificate once it reads
+		if _, err := conn.Read(make([]byte, 1)); err != nil && err != io.EOF {
+			return tls.ConnectionState{}, err
+		}
+		return conn.ConnectionState(), nil
+	}
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		state, err := handshake(config.RedisTLSConfig{CAFile: caFile, CertFile: clientCert, KeyFile: clientKey, ServerName: "localhost"})
+		require.NoError(t, err)
+		assert.Equal(t, uint16(tls.VersionTLS13), state.Version)
+	})
+
+	t.Run("client without certificate", func(t *testing.T) {
+		_, err := handshake(config.RedisTLSConfig{CAFile: caFile, ServerName: "localhost"})
+		assert.Error(t, err)
+	})
+
+	t.Run("server name mismatch", func(t *testing.T) {
+		_, err := handshake(config.RedisTLSConfig{CAFile: caFile, CertFile: clientCert, KeyFile: clientKey, ServerName: "redis"})
+		assert.Error(t, err)
+	})
+}
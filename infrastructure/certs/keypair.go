@@ -0,0 +1,108 @@
+package certs
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/PostService/infrastructure/logger"
+)
+
+// keyPair is certificate with private key read from files, which are read again once they change
+// Files are checked when certificate is requested, at most once per interval
+type keyPair struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+	log      logger.Logger
+	now      func() time.Time
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	stamps  [2]stamp
+	checked time.Time
+}
+
+// stamp tells versions of file apart
+type stamp struct {
+	modified time.Time
+	size     int64
+}
+
+// newKeyPair reads certificate and key, files which can not be read are reported
+func newKeyPair(certFile, keyFile string, interval time.Duration, log logger.Logger) (*keyPair, error) {
+	kp := &keyPair{certFile: certFile, keyFile: keyFile, interval: interval, log: log, now: time.Now}
+	stamps, err := kp.stat()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	kp.cert, kp.stamps, kp.checked = &cert, stamps, kp.now()
+	return kp, nil
+}
+
+// certificate return current certificate, changed files are read again
+// Certificate which can not be read is logged and the previous one is kept until files change again
+func (kp *keyPair) certificate() *tls.Certificate {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+	now := kp.now()
+	if now.Sub(kp.checked) < kp.interval {
+		return kp.cert
+	}
+	kp.checked = now
+
+	stamps, err := kp.stat()
+	if err != nil {
+		kp.log.Errorf("can not check certificate %s: %v", kp.certFile, err)
+		return kp.cert
+	}
+	if stamps == kp.stamps {
+		return kp.cert
+	}
+	cert, err := tls.LoadX509KeyPair(kp.certFile, kp.keyFile)
+	if err != nil {
+		// files may be replaced one by one, so they are read again on the next check
+		kp.log.Errorf("can not reload certificate %s, previous one is used: %v", kp.certFile, err)
+		return kp.cert
+	}
+	kp.cert, kp.stamps = &cert, stamps
+	kp.log.Printf("certificate %s reloaded", kp.certFile)
+	return kp.cert
+}
+
+// getCertificate serves certificate to clients
+func (kp *keyPair) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return kp.certificate(), nil
+}
+
+// getClientCertificate presents certificate to servers
+func (kp *keyPair) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return kp.certificate(), nil
+}
+
+// stat return stamps of certificate and key files
+func (kp *keyPair) stat() ([2]stamp, error) {
+	stamps := [2]stamp{}
+	for i, name := range []string{kp.certFile, kp.keyFile} {
+		st, err := fileStamp(name)
+		if err != nil {
+			return stamps, err
+		}
+		stamps[i] = st
+	}
+	return stamps, nil
+}
+
+// fileStamp return stamp of current version of file
+func fileStamp(name string) (stamp, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return stamp{}, err
+	}
+	return stamp{modified: info.ModTime(), size: info.Size()}, nil
+}
@@ -9,12 +9,27 @@ import (
 	"os"
 )
 
+// environmentVariable overrides environment of configuration file
+const environmentVariable = "POST_SERVICE_ENV"
+
 type (
 	// Configuration is struct for holding service's configuration info
 	Configuration struct {
-		ListenPort string       `json:"ListenPort" validate:"required"`
-		Redis      RedisConfig  `json:"RedisConfig" validate:"required"`
-		Log        LoggerConfig `json:"Log" validate:"required"`
+		ListenPort  string            `json:"ListenPort" validate:"required"`
+		Redis       RedisConfig       `json:"RedisConfig" validate:"required"`
+		Log         LoggerConfig      `json:"Log" validate:"required"`
+		Posts       PostsConfig       `json:"Posts"`
+		Trash       TrashConfig       `json:"Trash"`
+		HTTPCache   HTTPCacheConfig   `json:"HTTPCache"`
+		Auth        AuthConfig        `json:"Auth"`
+		RateLimit   RateLimitConfig   `json:"RateLimit"`
+		CORS        CORSConfig        `json:"CORS"`
+		Requests    RequestsConfig    `json:"Requests"`
+		TLS         TLSConfig         `json:"TLS"`
+		ReadCache   ReadCacheConfig   `json:"ReadCache"`
+		Compression CompressionConfig `json:"Compression"`
+		// Environment selects per environment settings, it is overridden by POST_SERVICE_ENV variable
+		Environment string `json:"Environment"`
 	}
 
 	// LoggerConfig is a struct for holding logger configuration
@@ -24,11 +39,197 @@ type (
 		FileName    string `json:"FileName" validate:"required"`
 	}
 
+	// PostsConfig is configuration of posts storage
+	// Zero MaxRevisions means default amount of revisions kept per post,
+	// RequireIfMatch rejects updates and deletes without expected post version,
+	// scheduled posts are published every PublishIntervalSeconds, every minute if zero
+	PostsConfig struct {
+		MaxRevisions           int64 `json:"MaxRevisions"`
+		RequireIfMatch         bool  `json:"RequireIfMatch"`
+		PublishIntervalSeconds int   `json:"PublishIntervalSeconds"`
+	}
+
+	// TrashConfig is configuration of deleted posts purging
+	// Zero RetentionHours disables purging, so deleted posts are kept until restored
+	TrashConfig struct {
+		RetentionHours       int `json:"RetentionHours"`
+		PurgeIntervalMinutes int `json:"PurgeIntervalMinutes"`
+	}
+
+	// HTTPCacheConfig is configuration of HTTP caching of posts responses
+	// CacheControl is sent as is with posts lists and posts, empty value omits the header.
+	// Encoded bytes of up to ResponseEntries posts lists responses taking at most
+	// ResponseMaxBytes (16 MiB if zero) are kept until lists change, zero ResponseEntries keeps none
+	HTTPCacheConfig struct {
+		CacheControl     string `json:"CacheControl"`
+		ResponseEntries  int    `json:"ResponseEntries"`
+		ResponseMaxBytes int64  `json:"ResponseMaxBytes"`
+	}
+
+	// CompressionConfig is configuration of response compression, responses are sent
+	// uncompressed unless Enabled. Responses of at least MinBytes (1024 if zero) are compressed
+	// by brotli or gzip accepted by client. Zero GzipLevel and BrotliQuality mean default
+	// compression of the codings
+	CompressionConfig struct {
+		Enabled       bool `json:"Enabled"`
+		MinBytes      int  `json:"MinBytes"`
+		GzipLevel     int  `json:"GzipLevel"`
+		BrotliQuality int  `json:"BrotliQuality"`
+	}
+
+	// AuthConfig is configuration of request authentication
+	// Once any method is configured, requests changing posts must be authenticated
+	AuthConfig struct {
+		JWT     JWTConfig      `json:"JWT"`
+		APIKeys []APIKeyConfig `json:"APIKeys"`
+	}
+
+	// JWTConfig is configuration of bearer JWT verification
+	// HS256 tokens are signed by Secret, RS256 ones by keys from JWKSFile or JWKSURL,
+	// Issuer and Audience are checked when set. Principal name and roles are read from
+	// NameClaim ("sub" by default, subject if missing) and RolesClaim ("roles" by default).
+	// Posts are owned by subject, principal name is author name of its posts and should be
+	// unique too, display names like "name" list posts of namesakes together
+	JWTConfig struct {
+		Secret     string `json:"Secret"`
+		JWKSFile   string `json:"JWKSFile"`
+		JWKSURL    string `json:"JWKSURL"`
+		Issuer     string `json:"Issuer"`
+		Audience   string `json:"Audience"`
+		NameClaim  string `json:"NameClaim"`
+		RolesClaim string `json:"RolesClaim"`
+	}
+
+	// APIKeyConfig is static API key of client sent in X-API-Key header
+	APIKeyConfig struct {
+		Key   string   `json:"Key"`
+		Name  string   `json:"Name"`
+		Roles []string `json:"Roles"`
+	}
+
+	// RequestsConfig is configuration of request bodies
+	// Bodies larger than MaxBodyBytes are rejected, 1 MiB is allowed if zero.
+	// AllowUnknownFields accepts bodies with fields service does not know for older clients
+	RequestsConfig struct {
+		MaxBodyBytes       int64 `json:"MaxBodyBytes"`
+		AllowUnknownFields bool  `json:"AllowUnknownFields"`
+	}
+
+	// ReadCacheConfig is configuration of in process cache of post reads
+	// Up to MaxEntries reads (10000 if zero) are kept for TTLSeconds (30 if zero) or until posts change
+	ReadCacheConfig struct {
+		Enabled    bool `json:"Enabled"`
+		MaxEntries int  `json:"MaxEntries"`
+		TTLSeconds int  `json:"TTLSeconds"`
+	}
+
+	// TLSConfig is configuration of TLS served by listener, plain HTTP is served without CertFile
+	// MinVersion is "1.2" (default) or "1.3". Clients are asked for certificates signed by
+	// ClientCAFile if it is set, RequireClientCert rejects clients without them.
+	// Files are read again once they change, checked at most every ReloadSeconds (10 if zero)
+	TLSConfig struct {
+		CertFile          string `json:"CertFile"`
+		KeyFile           string `json:"KeyFile"`
+		MinVersion        string `json:"MinVersion"`
+		ClientCAFile      string `json:"ClientCAFile"`
+		RequireClientCert bool   `json:"RequireClientCert"`
+		ReloadSeconds     int    `json:"ReloadSeconds"`
+	}
+
+	// RateLimitConfig is configuration of per client request rate limits
+	// Every client may make Requests per WindowSeconds to every route, Routes override
+	// limits of single routes, zero Requests leaves routes not listed in Routes unlimited.
+	// Clients are told apart by authenticated principal or by IP, X-Forwarded-For header
+	// is used for IP only with TrustForwardedFor when service runs behind proxy. Its addresses
+	// are taken from the right skipping TrustedProxies (IPs or CIDRs), the first other address
+	// is the client, so clients can not spoof it by sending the header themselves. Without
+	// TrustedProxies only the peer of service is trusted, with them the header is used only
+	// when the peer is one of them.
+	// Requests carrying credentials are also limited to AuthRequests per AuthWindowSeconds
+	// per IP before they are authenticated, zero AuthRequests leaves them unlimited
+	RateLimitConfig struct {
+		Requests          int                `json:"Requests"`
+		WindowSeconds     int                `json:"WindowSeconds"`
+		TrustForwardedFor bool               `json:"TrustForwardedFor"`
+		TrustedProxies    []string           `json:"TrustedProxies"`
+		Routes            []RouteLimitConfig `json:"Routes"`
+		AuthRequests      int                `json:"AuthRequests"`
+		AuthWindowSeconds int                `json:"AuthWindowSeconds"`
+	}
+
+	// RouteLimitConfig is rate limit of route given by method and path template as "POST /post",
+	// zero Requests leaves route unlimited
+	RouteLimitConfig struct {
+		Route         string `json:"Route"`
+		Requests      int    `json:"Requests"`
+		WindowSeconds int    `json:"WindowSeconds"`
+	}
+
+	// CORSConfig is cross-origin resource sharing policy of browser clients
+	// Origins are exact ("https://example.com") or patterns with wildcard subdomains
+	// ("https://*.example.com"), "*" allows any origin but not together with AllowCredentials.
+	// Environments lists origins per environment, AllowedOrigins are used for environments not
+	// listed. Empty AllowedMethods and AllowedHeaders allow methods and headers used by service
+	CORSConfig struct {
+		AllowedOrigins   []string            `json:"AllowedOrigins"`
+		Environments     map[string][]string `json:"Environments"`
+		AllowedMethods   []string            `json:"AllowedMethods"`
+		AllowedHeaders   []string            `json:"AllowedHeaders"`
+		ExposedHeaders   []string            `json:"ExposedHeaders"`
+		AllowCredentials bool                `json:"AllowCredentials"`
+		MaxAgeSeconds    int                 `json:"MaxAgeSeconds"`
+	}
+
 	// RedisConfig is redis configuration
+	// Mode is single (default) served at Address, sentinel which asks SentinelAddrs for
+	// address of MasterName master, or cluster discovered from ClusterAddrs seed nodes.
+	// In cluster all posts are kept in one hash slot, so cluster adds availability but
+	// no sharding: one master holds and serves the whole dataset
+	// Zero pool, timeout and retry settings keep defaults of redis client, negative
+	// ReadTimeoutMs and WriteTimeoutMs disable timeouts
 	RedisConfig struct {
-		Address  string `json:"Address" validate:"required"`
-		Password string `json:"Password" validate:"required"`
-		DB       int    `json:"DB" validate:"required"`
+		Mode              string               `json:"Mode"`
+		Address           string               `json:"Address" validate:"required"`
+		MasterName        string               `json:"MasterName"`
+		SentinelAddrs     []string             `json:"SentinelAddrs"`
+		ClusterAddrs      []string             `json:"ClusterAddrs"`
+		Password          string               `json:"Password" validate:"required"`
+		DB                int                  `json:"DB" validate:"required"`
+		PoolSize          int                  `json:"PoolSize"`
+		MinIdleConns      int                  `json:"MinIdleConns"`
+		DialTimeoutMs     int                  `json:"DialTimeoutMs"`
+		ReadTimeoutMs     int                  `json:"ReadTimeoutMs"`
+		WriteTimeoutMs    int                  `json:"WriteTimeoutMs"`
+		PoolTimeoutMs     int                  `json:"PoolTimeoutMs"`
+		MaxRetries        int                  `json:"MaxRetries"`
+		MinRetryBackoffMs int                  `json:"MinRetryBackoffMs"`
+		MaxRetryBackoffMs int                  `json:"MaxRetryBackoffMs"`
+		CircuitBreaker    CircuitBreakerConfig `json:"CircuitBreaker"`
+		TLS               RedisTLSConfig       `json:"TLS"`
+	}
+
+	// RedisTLSConfig is configuration of TLS connections to redis
+	// Server certificate is verified by CAFile or by system roots if it is not set and must be
+	// issued to ServerName, host of redis address if empty. CertFile and KeyFile are client
+	// certificate, read again once they change, checked at most every ReloadSeconds. CAFile is
+	// read at start, so changed CA is used after restart
+	RedisTLSConfig struct {
+		Enabled       bool   `json:"Enabled"`
+		CAFile        string `json:"CAFile"`
+		CertFile      string `json:"CertFile"`
+		KeyFile       string `json:"KeyFile"`
+		ServerName    string `json:"ServerName"`
+		MinVersion    string `json:"MinVersion"`
+		ReloadSeconds int    `json:"ReloadSeconds"`
+	}
+
+	// CircuitBreakerConfig is configuration of failing fast while redis is unhealthy
+	// Breaker opens after FailureThreshold failures in a row and rejects requests for OpenSeconds,
+	// then closes once HalfOpenProbes requests succeed. Zero FailureThreshold disables breaker
+	CircuitBreakerConfig struct {
+		FailureThreshold int `json:"FailureThreshold"`
+		OpenSeconds      int `json:"OpenSeconds"`
+		HalfOpenProbes   int `json:"HalfOpenProbes"`
 	}
 )
 
@@ -43,6 +244,9 @@ func New(configFilePath string) (config Configuration, err error) {
 	if config, err = readConfigJSON(configFilePath); err != nil {
 		return
 	}
+	if env := os.Getenv(environmentVariable); env != "" {
+		config.Environment = env
+	}
 	return
 }
 
@@ -1,14 +1,121 @@
 package datastore
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PostService/infrastructure/certs"
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/infrastructure/logger"
 	"github.com/go-redis/redis"
 )
 
-// NewRedis connects to redis and return redis client
-func NewRedis(opts redis.Options) (client *redis.Client, err error) {
-	client = redis.NewClient(&opts)
+// Redis deployment modes of configuration
+const (
+	ModeSingle   = "single"
+	ModeSentinel = "sentinel"
+	ModeCluster  = "cluster"
+)
+
+// NewRedis connects to redis deployed in configured mode and return redis client
+func NewRedis(conf config.RedisConfig, log logger.Logger) (client redis.UniversalClient, err error) {
+	if client, err = newClient(conf, log); err != nil {
+		return nil, err
+	}
 	if _, err = client.Ping().Result(); err != nil {
+		client.Close()
 		return nil, err
 	}
 	return
 }
+
+// newClient return client of redis deployed in configured mode
+func newClient(conf config.RedisConfig, log logger.Logger) (redis.UniversalClient, error) {
+	opts := RedisOptions(conf)
+	tlsConf, err := certs.ClientConfig(conf.TLS, log)
+	if err != nil {
+		return nil, err
+	}
+	opts.TLSConfig = tlsConf
+	switch conf.Mode {
+	case "", ModeSingle:
+		return redis.NewClient(&opts), nil
+	case ModeSentinel:
+		if conf.MasterName == "" || len(conf.SentinelAddrs) == 0 {
+			return nil, errors.New("redis sentinel mode requires MasterName and SentinelAddrs")
+		}
+		return redis.NewFailoverClient(failoverOptions(conf, opts)), nil
+	case ModeCluster:
+		if len(conf.ClusterAddrs) == 0 {
+			return nil, errors.New("redis cluster mode requires ClusterAddrs")
+		}
+		return redis.NewClusterClient(clusterOptions(conf, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", conf.Mode)
+	}
+}
+
+// RedisOptions return redis client options of configuration
+func RedisOptions(conf config.RedisConfig) redis.Options {
+	return redis.Options{
+		Addr:            conf.Address,
+		Password:        conf.Password,
+		DB:              conf.DB,
+		PoolSize:        conf.PoolSize,
+		MinIdleConns:    conf.MinIdleConns,
+		DialTimeout:     millis(conf.DialTimeoutMs),
+		ReadTimeout:     millis(conf.ReadTimeoutMs),
+		WriteTimeout:    millis(conf.WriteTimeoutMs),
+		PoolTimeout:     millis(conf.PoolTimeoutMs),
+		MaxRetries:      conf.MaxRetries,
+		MinRetryBackoff: millis(conf.MinRetryBackoffMs),
+		MaxRetryBackoff: millis(conf.MaxRetryBackoffMs),
+	}
+}
+
+// failoverOptions return options of client which asks sentinels for address of master
+func failoverOptions(conf config.RedisConfig, opts redis.Options) *redis.FailoverOptions {
+	return &redis.FailoverOptions{
+		MasterName:      conf.MasterName,
+		SentinelAddrs:   conf.SentinelAddrs,
+		Password:        opts.Password,
+		DB:              opts.DB,
+		PoolSize:        opts.PoolSize,
+		MinIdleConns:    opts.MinIdleConns,
+		DialTimeout:     opts.DialTimeout,
+		ReadTimeout:     opts.ReadTimeout,
+		WriteTimeout:    opts.WriteTimeout,
+		PoolTimeout:     opts.PoolTimeout,
+		MaxRetries:      opts.MaxRetries,
+		MinRetryBackoff: opts.MinRetryBackoff,
+		MaxRetryBackoff: opts.MaxRetryBackoff,
+		TLSConfig:       opts.TLSConfig,
+	}
+}
+
+// clusterOptions return options of cluster client, cluster has no databases, so DB is not used
+func clusterOptions(conf config.RedisConfig, opts redis.Options) *redis.ClusterOptions {
+	return &redis.ClusterOptions{
+		Addrs:           conf.ClusterAddrs,
+		Password:        opts.Password,
+		PoolSize:        opts.PoolSize,
+		MinIdleConns:    opts.MinIdleConns,
+		DialTimeout:     opts.DialTimeout,
+		ReadTimeout:     opts.ReadTimeout,
+		WriteTimeout:    opts.WriteTimeout,
+		PoolTimeout:     opts.PoolTimeout,
+		MaxRetries:      opts.MaxRetries,
+		MinRetryBackoff: opts.MinRetryBackoff,
+		MaxRetryBackoff: opts.MaxRetryBackoff,
+		TLSConfig:       opts.TLSConfig,
+	}
+}
+
+// millis return duration of ms milliseconds, negative ms stays negative as client reads it as disabled
+func millis(ms int) time.Duration {
+	if ms < 0 {
+		return -1
+	}
+	return time.Duration(ms) * time.Millisecond
+}
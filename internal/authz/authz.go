@@ -0,0 +1,108 @@
+package authz
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/PostService/model"
+)
+
+// Roles granted to principals
+const (
+	// RoleWriter creates posts and manages own posts
+	RoleWriter = "writer"
+	// RoleEditor also edits, publishes and reviews posts of other authors
+	RoleEditor = "editor"
+	// RoleAdmin manages posts of all authors
+	RoleAdmin = "admin"
+)
+
+// Action is operation on posts checked by Authorize
+type Action string
+
+// Actions on posts
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	// ActionDelete covers moving post to trash and restoring it
+	ActionDelete Action = "delete"
+	// ActionPublish covers publishing and unpublishing
+	ActionPublish Action = "publish"
+	// ActionView is seeing unpublished posts
+	ActionView Action = "view unpublished"
+)
+
+// Scope tells whose posts role may act on
+type Scope int
+
+// Scopes of permissions, wider scope includes narrower one
+const (
+	ScopeNone Scope = iota
+	ScopeOwn
+	ScopeAll
+)
+
+// permissions lists scope of every action per role, actions not listed are not permitted
+var permissions = map[string]map[Action]Scope{
+	RoleWriter: {
+		ActionCreate:  ScopeOwn,
+		ActionUpdate:  ScopeOwn,
+		ActionDelete:  ScopeOwn,
+		ActionPublish: ScopeOwn,
+		ActionView:    ScopeOwn,
+	},
+	RoleEditor: {
+		ActionCreate:  ScopeOwn,
+		ActionUpdate:  ScopeAll,
+		ActionDelete:  ScopeOwn,
+		ActionPublish: ScopeAll,
+		ActionView:    ScopeAll,
+	},
+	RoleAdmin: {
+		ActionCreate:  ScopeAll,
+		ActionUpdate:  ScopeAll,
+		ActionDelete:  ScopeAll,
+		ActionPublish: ScopeAll,
+		ActionView:    ScopeAll,
+	},
+}
+
+// ErrForbidden returned when viewer is not permitted to act on posts
+var ErrForbidden = errors.New("forbidden")
+
+// Allowed return widest scope of action granted to viewer by its roles
+// Authenticated viewers without roles are writers, anonymous viewers are granted nothing
+func Allowed(viewer model.Viewer, action Action) Scope {
+	if viewer.Name == "" {
+		return ScopeNone
+	}
+	roles := viewer.Roles
+	if len(roles) == 0 {
+		roles = []string{RoleWriter}
+	}
+	scope := ScopeNone
+	for _, role := range roles {
+		if s := permissions[role][action]; s > scope {
+			scope = s
+		}
+	}
+	return scope
+}
+
+// Authorize return ErrForbidden with reason unless viewer may act on post,
+// own tells whether viewer is the post author
+func Authorize(viewer model.Viewer, action Action, own bool) error {
+	switch Allowed(viewer, action) {
+	case ScopeAll:
+		return nil
+	case ScopeOwn:
+		if own {
+			return nil
+		}
+		return fmt.Errorf("%w: %s may %s own posts only", ErrForbidden, viewer.Name, action)
+	}
+	if viewer.Name == "" {
+		return fmt.Errorf("%w: anonymous client may not %s posts", ErrForbidden, action)
+	}
+	return fmt.Errorf("%w: %s may not %s posts", ErrForbidden, viewer.Name, action)
+}
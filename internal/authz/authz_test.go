@@ -0,0 +1,42 @@
+package authz
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PostService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	writer := model.Viewer{Name: "alice"}
+	editor := model.Viewer{Name: "ed", Roles: []string{RoleEditor}}
+	admin := model.Viewer{Name: "root", Roles: []string{"reader", RoleAdmin}}
+	var testCases = []struct {
+		name   string
+		viewer model.Viewer
+		action Action
+		own    bool
+		err    string
+	}{
+		{name: "writer updates own post", viewer: writer, action: ActionUpdate, own: true},
+		{name: "writer updates post of other author", viewer: writer, action: ActionUpdate, err: "forbidden: alice may update own posts only"},
+		{name: "editor updates post of other author", viewer: editor, action: ActionUpdate},
+		{name: "editor deletes post of other author", viewer: editor, action: ActionDelete, err: "forbidden: ed may delete own posts only"},
+		{name: "admin deletes post of other author", viewer: admin, action: ActionDelete},
+		{name: "unknown role", viewer: model.Viewer{Name: "bob", Roles: []string{"reader"}}, action: ActionCreate, own: true,
+			err: "forbidden: bob may not create posts"},
+		{name: "anonymous", action: ActionView, err: "forbidden: anonymous client may not view unpublished posts"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Authorize(tc.viewer, tc.action, tc.own)
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrForbidden))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"strconv"
+
+	"github.com/go-redis/redis"
+)
+
+// count queues changes of counters of published entry by delta: posts count and date of the latest
+// post of author, posts of stat buckets and of author inside them and amount of posts of tags
+// It must be queued after indexes are changed, so the latest post of author is found among posts left in them
+// Scripts are sent by EVAL, as EVALSHA failed inside transaction can not be retried with script source
+func (pr *postCache) count(pipe redis.Pipeliner, e Entry, delta int64) {
+	if !e.Published {
+		return
+	}
+	if delta > 0 {
+		// author registered in the authors index keeps its name as display value
+		addAuthorPostScript.Eval(pipe, []string{pr.key(authorsKey), pr.key(authorKeyPrefix + e.Author)},
+			e.Author, e.AuthorName, e.Date)
+	} else {
+		keys := []string{pr.key(authorsKey), pr.key(authorKeyPrefix + e.Author), pr.key(authorIndexPrefix + e.Author), pr.key(publishedIndexKey)}
+		removeAuthorPostScript.Eval(pipe, keys, e.Author)
+	}
+	for _, bucket := range e.Buckets {
+		pipe.HIncrBy(pr.key(statsTotalsKey), bucket, delta)
+		pipe.ZIncrBy(pr.key(statsKeyPrefix+bucket), float64(delta), e.Author)
+	}
+	for _, tag := range e.Tags {
+		pipe.ZIncrBy(pr.key(tagsKey), float64(delta), tag)
+	}
+}
+
+// addAuthorPostScript registers author in the authors index and updates author's posts count
+// and date of the latest post
+var addAuthorPostScript = redis.NewScript(`
+redis.call('ZADD', KEYS[1], 0, ARGV[1])
+redis.call('HINCRBY', KEYS[2], 'posts', 1)
+redis.call('HSET', KEYS[2], 'name', ARGV[2])
+local latest = tonumber(redis.call('HGET', KEYS[2], 'latest'))
+if not latest or latest < tonumber(ARGV[3]) then
+	redis.call('HSET', KEYS[2], 'latest', ARGV[3])
+end
+return 1
+`)
+
+// removeAuthorPostScript decrements author's posts count and updates date of the latest post
+// from the latest published post of author index, author without posts is removed from the authors index
+var removeAuthorPostScript = redis.NewScript(`
+local posts = redis.call('HINCRBY', KEYS[2], 'posts', -1)
+if posts <= 0 then
+	redis.call('DEL', KEYS[2])
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	return 0
+end
+local start = 0
+while true do
+	local page = redis.call('ZREVRANGE', KEYS[3], start, start + 99, 'WITHSCORES')
+	if #page == 0 then
+		return posts
+	end
+	for i = 1, #page, 2 do
+		if redis.call('ZSCORE', KEYS[4], page[i]) then
+			redis.call('HSET', KEYS[2], 'latest', page[i + 1])
+			return posts
+		end
+	end
+	start = start + 100
+end
+`)
+
+// GetAuthors return page of authors which keys start with prefix ordered by key
+// and total amount of such authors
+func (pr *postCache) GetAuthors(prefix string, offset, count int64) ([]Author, int64, error) {
+	min, max := "-", "+"
+	if prefix != "" {
+		// 0xff byte never occurs in UTF-8, so it is greater than any continuation of prefix
+		min, max = "["+prefix, "["+prefix+"\xff"
+	}
+	pipe := pr.rc.Pipeline()
+	keysCmd := pipe.ZRangeByLex(pr.key(authorsKey), redis.ZRangeBy{Min: min, Max: max, Offset: offset, Count: count})
+	totalCmd := pipe.ZLexCount(pr.key(authorsKey), min, max)
+	if _, err := pipe.Exec(); err != nil {
+		return nil, 0, err
+	}
+
+	keys := keysCmd.Val()
+	pipe = pr.rc.Pipeline()
+	stats := make([]*redis.StringStringMapCmd, 0, len(keys))
+	for _, key := range keys {
+		stats = append(stats, pipe.HGetAll(pr.key(authorKeyPrefix+key)))
+	}
+	if len(keys) > 0 {
+		if _, err := pipe.Exec(); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	authors := make([]Author, 0, len(keys))
+	for i, key := range keys {
+		stat := stats[i].Val()
+		author := Author{Key: key, Name: stat["name"]}
+		author.Posts, _ = strconv.ParseInt(stat["posts"], 10, 64)
+		author.Latest, _ = strconv.ParseInt(stat["latest"], 10, 64)
+		authors = append(authors, author)
+	}
+	return authors, totalCmd.Val(), nil
+}
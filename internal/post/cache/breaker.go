@@ -0,0 +1,254 @@
+package cache
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/PostService/infrastructure/breaker"
+)
+
+// breakerCache is PostCache failing fast with breaker.ErrOpen while redis is unhealthy
+type breakerCache struct {
+	next PostCache
+	b    *breaker.Breaker
+}
+
+// NewBreakerCache return PostCache calling next through breaker b
+func NewBreakerCache(next PostCache, b *breaker.Breaker) PostCache {
+	return &breakerCache{next: next, b: b}
+}
+
+// call calls fn through breaker, methods of breakerCache call next this way
+func (c *breakerCache) call(fn func() error) error {
+	return c.b.Call(fn, Unavailable)
+}
+
+// Unavailable reports whether error means that redis can not serve requests,
+// replies like redis.Nil or ErrConflict are answers of healthy redis
+func Unavailable(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	msg := err.Error()
+	switch msg {
+	case "redis: connection pool timeout", "redis: client is closed":
+		return true
+	}
+	for _, prefix := range []string{"LOADING ", "MASTERDOWN ", "CLUSTERDOWN ", "TRYAGAIN "} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *breakerCache) NextID() (string, error) {
+	var id string
+	err := c.call(func() (err error) {
+		id, err = c.next.NextID()
+		return err
+	})
+	return id, err
+}
+
+func (c *breakerCache) SavePost(e Entry) error {
+	return c.call(func() error { return c.next.SavePost(e) })
+}
+
+func (c *breakerCache) CreatePost(e Entry, h History) error {
+	return c.call(func() error { return c.next.CreatePost(e, h) })
+}
+
+func (c *breakerCache) UpdatePost(old, e Entry, h History) error {
+	return c.call(func() error { return c.next.UpdatePost(old, e, h) })
+}
+
+func (c *breakerCache) GetRevisions(id string) ([]string, error) {
+	var values []string
+	err := c.call(func() (err error) {
+		values, err = c.next.GetRevisions(id)
+		return err
+	})
+	return values, err
+}
+
+func (c *breakerCache) GetPosts(ids []string) ([]string, error) {
+	var values []string
+	err := c.call(func() (err error) {
+		values, err = c.next.GetPosts(ids)
+		return err
+	})
+	return values, err
+}
+
+func (c *breakerCache) FindPosts(q IndexQuery) ([]string, int64, error) {
+	var values []string
+	var total int64
+	err := c.call(func() (err error) {
+		values, total, err = c.next.FindPosts(q)
+		return err
+	})
+	return values, total, err
+}
+
+func (c *breakerCache) IndexVersion(q IndexQuery) (Version, error) {
+	var version Version
+	err := c.call(func() (err error) {
+		version, err = c.next.IndexVersion(q)
+		return err
+	})
+	return version, err
+}
+
+func (c *breakerCache) PostVersion(id string) (Version, error) {
+	var version Version
+	err := c.call(func() (err error) {
+		version, err = c.next.PostVersion(id)
+		return err
+	})
+	return version, err
+}
+
+func (c *breakerCache) Search(terms []string, offset, count int64) ([]string, int64, error) {
+	var values []string
+	var total int64
+	err := c.call(func() (err error) {
+		values, total, err = c.next.Search(terms, offset, count)
+		return err
+	})
+	return values, total, err
+}
+
+func (c *breakerCache) DuePosts(before, count int64) ([]string, error) {
+	var values []string
+	err := c.call(func() (err error) {
+		values, err = c.next.DuePosts(before, count)
+		return err
+	})
+	return values, err
+}
+
+func (c *breakerCache) Notify(event string) error {
+	return c.call(func() error { return c.next.Notify(event) })
+}
+
+func (c *breakerCache) Invalidate(invalidation string) error {
+	return c.call(func() error { return c.next.Invalidate(invalidation) })
+}
+
+func (c *breakerCache) Invalidations(stop <-chan struct{}) (<-chan string, error) {
+	var invalidations <-chan string
+	err := c.call(func() (err error) {
+		invalidations, err = c.next.Invalidations(stop)
+		return err
+	})
+	return invalidations, err
+}
+
+func (c *breakerCache) TrashPost(old string, e Entry, deletedAt int64) error {
+	return c.call(func() error { return c.next.TrashPost(old, e, deletedAt) })
+}
+
+func (c *breakerCache) IndexTrash(e Entry, deletedAt int64) error {
+	return c.call(func() error { return c.next.IndexTrash(e, deletedAt) })
+}
+
+func (c *breakerCache) RestorePost(old string, e Entry) (bool, error) {
+	var ok bool
+	err := c.call(func() (err error) {
+		ok, err = c.next.RestorePost(old, e)
+		return err
+	})
+	return ok, err
+}
+
+func (c *breakerCache) GetTrash(owner string, offset, count int64) ([]string, int64, error) {
+	var values []string
+	var total int64
+	err := c.call(func() (err error) {
+		values, total, err = c.next.GetTrash(owner, offset, count)
+		return err
+	})
+	return values, total, err
+}
+
+func (c *breakerCache) PurgeTrash(before int64, count int64) (int64, error) {
+	var n int64
+	err := c.call(func() (err error) {
+		n, err = c.next.PurgeTrash(before, count)
+		return err
+	})
+	return n, err
+}
+
+func (c *breakerCache) GetAuthors(prefix string, offset, count int64) ([]Author, int64, error) {
+	var authors []Author
+	var total int64
+	err := c.call(func() (err error) {
+		authors, total, err = c.next.GetAuthors(prefix, offset, count)
+		return err
+	})
+	return authors, total, err
+}
+
+func (c *breakerCache) CountPosts(buckets []string) ([]int64, error) {
+	var counts []int64
+	err := c.call(func() (err error) {
+		counts, err = c.next.CountPosts(buckets)
+		return err
+	})
+	return counts, err
+}
+
+func (c *breakerCache) TopAuthors(buckets []string, count int64) ([]Author, error) {
+	var authors []Author
+	err := c.call(func() (err error) {
+		authors, err = c.next.TopAuthors(buckets, count)
+		return err
+	})
+	return authors, err
+}
+
+func (c *breakerCache) GetTags(offset, count int64) ([]Tag, int64, error) {
+	var tags []Tag
+	var total int64
+	err := c.call(func() (err error) {
+		tags, total, err = c.next.GetTags(offset, count)
+		return err
+	})
+	return tags, total, err
+}
+
+func (c *breakerCache) ScanKeys(match, typ string) ([]string, error) {
+	var values []string
+	err := c.call(func() (err error) {
+		values, err = c.next.ScanKeys(match, typ)
+		return err
+	})
+	return values, err
+}
+
+func (c *breakerCache) GetList(key string) ([]string, error) {
+	var values []string
+	err := c.call(func() (err error) {
+		values, err = c.next.GetList(key)
+		return err
+	})
+	return values, err
+}
+
+func (c *breakerCache) PostIDs() ([]string, error) {
+	var values []string
+	err := c.call(func() (err error) {
+		values, err = c.next.PostIDs()
+		return err
+	})
+	return values, err
+}
+
+func (c *breakerCache) DeleteKeys(keys ...string) error {
+	return c.call(func() error { return c.next.DeleteKeys(keys...) })
+}
@@ -1,38 +1,299 @@
 package cache
 
 import (
+	"errors"
+	"strconv"
+
 	"github.com/go-redis/redis"
 )
 
+const (
+	postIDKey         = "post:id"
+	postKeyPrefix     = "post:"
+	allIndexKey       = "idx:all"
+	publishedIndexKey = "idx:published"
+	nameIndexPrefix   = "idx:name:"
+	authorIndexPrefix = "idx:author:"
+	ownerIndexPrefix  = "idx:owner:"
+	tagIndexPrefix    = "idx:tag:"
+	queryTmpKey       = "query:tmp"
+	queryTagsTmpKey   = "query:tmp:tags"
+	queryOwnTmpKey    = "query:tmp:own"
+	termKeyPrefix     = "search:term:"
+	prefixKeyPrefix   = "search:prefix:"
+	searchTmpKey      = "search:tmp"
+	searchPubTmpKey   = "search:tmp:published"
+	authorsKey        = "authors"
+	authorKeyPrefix   = "author:"
+	statsTotalsKey    = "stats:totals"
+	statsKeyPrefix    = "stats:"
+	statsTmpKey       = "stats:tmp"
+	tagsKey           = "tags"
+	trashKey          = "trash"
+	trashOwnerPrefix  = "trash:owner:"
+	trashOwnersKey    = "trash:owners"
+	revisionsPrefix   = "revisions:"
+	scheduledKey      = "scheduled"
+	eventsChannel     = "events:posts"
+	invalidateChannel = "events:invalidate"
+	versionsKey       = "versions"
+	modifiedKey       = "versions:modified"
+
+	// clusterKeyTag is hash tag of all keys stored in redis cluster, indexes are intersected
+	// and changed in transactions together with post records, so they must share one slot.
+	// The whole dataset is therefore held by a single master and cluster does not shard it
+	clusterKeyTag = "{posts}"
+
+	// minPrefixLen is the shortest term prefix stored in search index
+	minPrefixLen = 2
+	// scanCount is amount of keys requested from redis per SCAN call
+	scanCount = 1000
+)
+
+// ErrConflict returned when post record was changed by concurrent writer
+var ErrConflict = errors.New("post record was changed concurrently")
+
 // PostCache used for redis logic related to post entity
 type PostCache interface {
-	GetPostsByKey(key string) ([]string, error)
-	InsertPost(key, post string) error
+	NextID() (string, error)
+	SavePost(e Entry) error
+	CreatePost(e Entry, h History) error
+	UpdatePost(old, e Entry, h History) error
+	GetRevisions(id string) ([]string, error)
+	GetPosts(ids []string) ([]string, error)
+	FindPosts(q IndexQuery) ([]string, int64, error)
+	IndexVersion(q IndexQuery) (Version, error)
+	PostVersion(id string) (Version, error)
+	Search(terms []string, offset, count int64) ([]string, int64, error)
+	DuePosts(before, count int64) ([]string, error)
+	Notify(event string) error
+	Invalidate(invalidation string) error
+	Invalidations(stop <-chan struct{}) (<-chan string, error)
+	TrashPost(old string, e Entry, deletedAt int64) error
+	IndexTrash(e Entry, deletedAt int64) error
+	RestorePost(old string, e Entry) (bool, error)
+	GetTrash(owner string, offset, count int64) ([]string, int64, error)
+	PurgeTrash(before int64, count int64) (int64, error)
+	GetAuthors(prefix string, offset, count int64) ([]Author, int64, error)
+	CountPosts(buckets []string) ([]int64, error)
+	TopAuthors(buckets []string, count int64) ([]Author, error)
+	GetTags(offset, count int64) ([]Tag, int64, error)
+	ScanKeys(match, typ string) ([]string, error)
+	GetList(key string) ([]string, error)
+	PostIDs() ([]string, error)
+	DeleteKeys(keys ...string) error
+}
+
+// Entry is encoded post with normalized keys of indexes it belongs to and identity of its owner
+// Published entries are listed publicly and counted in statistics of author, stat Buckets
+// and tags, AuthorName is display name of author. Scheduled ones wait to be published since their date
+type Entry struct {
+	ID         string
+	Post       string
+	Date       int64
+	Name       string
+	Author     string
+	AuthorName string
+	Owner      string
+	Tags       []string
+	Terms      []string
+	Buckets    []string
+	Published  bool
+	Scheduled  bool
+}
+
+// History is revisions appended to post history in the same transaction as post record,
+// at most Max latest revisions are kept
+type History struct {
+	Revisions []string
+	Max       int64
+}
+
+// Author is stored statistic of author's posts
+type Author struct {
+	Key    string
+	Name   string
+	Posts  int64
+	Latest int64
+}
+
+// Tag is stored amount of posts marked by tag
+type Tag struct {
+	Name  string
+	Posts int64
 }
 
 // NewPostCache return new PostCache realization
-func NewPostCache(rc *redis.Client) PostCache {
-	return &postCache{rc}
+// Keys stored in redis cluster are hash tagged, keys of other deployments keep their names.
+// Tagged keys share one slot, so in cluster all posts live on one master, which has to
+// fit the dataset and serve all its load, other masters stay unused by posts
+func NewPostCache(rc redis.UniversalClient) PostCache {
+	pr := &postCache{rc: rc}
+	if _, ok := rc.(*redis.ClusterClient); ok {
+		pr.tag = clusterKeyTag
+	}
+	return pr
 }
 
 type postCache struct {
-	rc *redis.Client
+	rc  redis.UniversalClient
+	tag string
+}
+
+// key return name of key stored in redis
+func (pr *postCache) key(name string) string {
+	return pr.tag + name
 }
 
-func (pr *postCache) GetPostsByKey(key string) ([]string, error) {
-	resp, err := pr.rc.LRange(key, 0, -1).Result()
+// NextID return new unique post identifier
+func (pr *postCache) NextID() (string, error) {
+	id, err := pr.rc.Incr(pr.key(postIDKey)).Result()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	return resp, nil
+	return strconv.FormatInt(id, 10), nil
 }
 
-func (pr *postCache) InsertPost(key, post string) error {
-	err := pr.rc.LPush(key, post).Err()
-	if err != nil {
+// SavePost stores post record by its id and adds it to global, name, author and tags indexes
+// scored by post date and to the inverted index of search terms
+// Every term is indexed as is and by all its prefixes, so partial words can be found too
+// Saving is idempotent, so the same entry can be saved again to rebuild indexes, counters
+// are not changed
+func (pr *postCache) SavePost(e Entry) error {
+	pipe := pr.rc.TxPipeline()
+	pipe.Set(pr.key(postKeyPrefix+e.ID), e.Post, 0)
+	pr.index(pipe, e)
+	pr.touch(pipe, pr.entryKeys(e)...)
+	if _, err := pipe.Exec(); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// CreatePost saves new post as SavePost does, counts it and appends history of post
+// in one transaction
+func (pr *postCache) CreatePost(e Entry, h History) error {
+	pipe := pr.rc.TxPipeline()
+	pipe.Set(pr.key(postKeyPrefix+e.ID), e.Post, 0)
+	pr.index(pipe, e)
+	pr.touch(pipe, pr.entryKeys(e)...)
+	pr.count(pipe, e, 1)
+	pr.appendHistory(pipe, e.ID, h)
+	if _, err := pipe.Exec(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePost replaces post record, moves post from indexes and counters of old entry to the ones
+// of new entry and appends history of post in one transaction
+// Return ErrConflict if record does not equal old one anymore
+func (pr *postCache) UpdatePost(old, e Entry, h History) error {
+	return pr.casPost(e.ID, old.Post, func(pipe redis.Pipeliner) {
+		pr.unindex(pipe, old)
+		pipe.Set(pr.key(postKeyPrefix+e.ID), e.Post, 0)
+		pr.index(pipe, e)
+		pr.touch(pipe, append(pr.entryKeys(old), pr.entryKeys(e)...)...)
+		// latest date of old author is recomputed from updated index before new entry is counted
+		pr.count(pipe, old, -1)
+		pr.count(pipe, e, 1)
+		pr.appendHistory(pipe, e.ID, h)
+	})
+}
+
+// casPost executes commands in transaction only if post record equals expected,
+// record is watched, so concurrent change aborts transaction with ErrConflict
+func (pr *postCache) casPost(id, expected string, fn func(pipe redis.Pipeliner)) error {
+	key := pr.key(postKeyPrefix + id)
+	err := pr.rc.Watch(func(tx *redis.Tx) error {
+		current, err := tx.Get(key).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if current != expected {
+			return ErrConflict
+		}
+		_, err = tx.TxPipelined(func(pipe redis.Pipeliner) error {
+			fn(pipe)
+			return nil
+		})
+		return err
+	}, key)
+	if err == redis.TxFailedErr {
+		return ErrConflict
+	}
+	return err
+}
+
+// index adds entry to all indexes it belongs to
+func (pr *postCache) index(pipe redis.Pipeliner, e Entry) {
+	byDate := redis.Z{Score: float64(e.Date), Member: e.ID}
+	pipe.ZAdd(pr.key(allIndexKey), byDate)
+	if e.Published {
+		pipe.ZAdd(pr.key(publishedIndexKey), byDate)
+	}
+	if e.Scheduled {
+		pipe.ZAdd(pr.key(scheduledKey), byDate)
+	}
+	pipe.ZAdd(pr.key(nameIndexPrefix+e.Name), byDate)
+	pipe.ZAdd(pr.key(authorIndexPrefix+e.Author), byDate)
+	if e.Owner != "" {
+		pipe.ZAdd(pr.key(ownerIndexPrefix+e.Owner), byDate)
+	}
+	for _, tag := range e.Tags {
+		pipe.ZAdd(pr.key(tagIndexPrefix+tag), byDate)
+	}
+	for _, term := range e.Terms {
+		pipe.ZAdd(pr.key(termKeyPrefix+term), redis.Z{Score: 1, Member: e.ID})
+		for _, prefix := range prefixes(term) {
+			pipe.ZAdd(pr.key(prefixKeyPrefix+prefix), redis.Z{Score: 1, Member: e.ID})
+		}
+	}
+}
+
+// unindex removes entry from all indexes it belongs to
+func (pr *postCache) unindex(pipe redis.Pipeliner, e Entry) {
+	pipe.ZRem(pr.key(allIndexKey), e.ID)
+	pipe.ZRem(pr.key(publishedIndexKey), e.ID)
+	pipe.ZRem(pr.key(scheduledKey), e.ID)
+	pipe.ZRem(pr.key(nameIndexPrefix+e.Name), e.ID)
+	pipe.ZRem(pr.key(authorIndexPrefix+e.Author), e.ID)
+	if e.Owner != "" {
+		pipe.ZRem(pr.key(ownerIndexPrefix+e.Owner), e.ID)
+	}
+	for _, tag := range e.Tags {
+		pipe.ZRem(pr.key(tagIndexPrefix+tag), e.ID)
+	}
+	for _, term := range e.Terms {
+		pipe.ZRem(pr.key(termKeyPrefix+term), e.ID)
+		for _, prefix := range prefixes(term) {
+			pipe.ZRem(pr.key(prefixKeyPrefix+prefix), e.ID)
+		}
+	}
+}
+
+// GetPosts return post records by ids, missing records are skipped
+func (pr *postCache) GetPosts(ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return []string{}, nil
+	}
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, pr.key(postKeyPrefix+id))
+	}
+	vals, err := pr.rc.MGet(keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]string, 0, len(vals))
+	for _, v := range vals {
+		if s, ok := v.(string); ok {
+			resp = append(resp, s)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis"
+)
+
+// IsInternalKey report whether key is list stored by current version, like post revisions,
+// so it is never taken for author or post name list of older versions
+func IsInternalKey(key string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(key, clusterKeyTag), revisionsPrefix)
+}
+
+// ScanKeys return keys matching pattern which hold values of given redis type
+// Every master of redis cluster holds its own keys, so all of them are scanned
+func (pr *postCache) ScanKeys(match, typ string) ([]string, error) {
+	cluster, ok := pr.rc.(*redis.ClusterClient)
+	if !ok {
+		return scanKeys(pr.rc, match, typ)
+	}
+	var (
+		mu   sync.Mutex
+		resp = []string{}
+	)
+	err := cluster.ForEachMaster(func(master *redis.Client) error {
+		keys, err := scanKeys(master, match, typ)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		resp = append(resp, keys...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// scanKeys return keys of single redis server matching pattern which hold values of given type
+func scanKeys(rc redis.Cmdable, match, typ string) ([]string, error) {
+	keys := []string{}
+	iter := rc.Scan(0, match, scanCount).Iterator()
+	for iter.Next() {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	pipe := rc.Pipeline()
+	types := make([]*redis.StatusCmd, 0, len(keys))
+	for _, key := range keys {
+		types = append(types, pipe.Type(key))
+	}
+	if _, err := pipe.Exec(); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	resp := []string{}
+	for i, key := range keys {
+		if types[i].Val() == typ {
+			resp = append(resp, key)
+		}
+	}
+	return resp, nil
+}
+
+// PostIDs return ids of all stored post records
+func (pr *postCache) PostIDs() ([]string, error) {
+	keys, err := pr.ScanKeys(pr.key(postKeyPrefix)+"*", "string")
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key != pr.key(postIDKey) {
+			ids = append(ids, strings.TrimPrefix(key, pr.key(postKeyPrefix)))
+		}
+	}
+	return ids, nil
+}
+
+// GetList return all values of list
+func (pr *postCache) GetList(key string) ([]string, error) {
+	resp, err := pr.rc.LRange(key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// DeleteKeys removes keys, every key is deleted by its own command as keys may live in different cluster slots
+func (pr *postCache) DeleteKeys(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	pipe := pr.rc.Pipeline()
+	for _, key := range keys {
+		pipe.Del(key)
+	}
+	_, err := pipe.Exec()
+	return err
+}
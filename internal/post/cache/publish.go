@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"strconv"
+
+	"github.com/go-redis/redis"
+)
+
+// DuePosts return at most count ids of scheduled posts dated not later than before, the earliest first
+func (pr *postCache) DuePosts(before, count int64) ([]string, error) {
+	return pr.rc.ZRangeByScore(pr.key(scheduledKey), redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(before, 10),
+		Count: count,
+	}).Result()
+}
+
+// Notify publishes encoded post event to subscribers of posts events channel
+func (pr *postCache) Notify(event string) error {
+	return pr.rc.Publish(eventsChannel, event).Err()
+}
+
+// Invalidate publishes encoded invalidation telling all replicas which posts read before were changed
+func (pr *postCache) Invalidate(invalidation string) error {
+	return pr.rc.Publish(invalidateChannel, invalidation).Err()
+}
+
+// Invalidations return encoded invalidations until stop is closed, then channel is closed
+// Subscription is restored by redis client after connection failures, invalidations sent
+// meanwhile are lost
+func (pr *postCache) Invalidations(stop <-chan struct{}) (<-chan string, error) {
+	ps := pr.rc.Subscribe(invalidateChannel)
+	if _, err := ps.Receive(); err != nil {
+		ps.Close()
+		return nil, err
+	}
+
+	invalidations := make(chan string, 16)
+	go func() {
+		defer close(invalidations)
+		defer ps.Close()
+		msgs := ps.Channel()
+		for {
+			select {
+			case <-stop:
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case invalidations <- msg.Payload:
+				case <-stop:
+					return
+				}
+			}
+		}
+	}()
+	return invalidations, nil
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"sort"
+
+	"github.com/go-redis/redis"
+)
+
+// IndexQuery describes lookup of post ids in indexes, all keys must be normalized
+// Min and Max bound post date in redis score range syntax, posts which are not published
+// are found only if Unpublished is set or if they are owned by Owner
+type IndexQuery struct {
+	Name        string
+	Author      string
+	Tags        []string
+	AnyTag      bool
+	Min         string
+	Max         string
+	Desc        bool
+	Offset      int64
+	Count       int64
+	Unpublished bool
+	Owner       string
+}
+
+// union is temporary index holding union of indexes, it is intersected with other indexes of query
+type union struct {
+	key  string
+	keys []string
+}
+
+// FindPosts return page of post ids matching all conditions of query and total amount of matches
+// Sizes of indexes are checked first, so query with empty index is answered without intersection
+// and the smallest index leads the intersection, query without filters reads the published
+// or the global index
+func (pr *postCache) FindPosts(q IndexQuery) ([]string, int64, error) {
+	keys, unions := pr.queryKeys(q)
+	if len(keys) > 0 {
+		sizes, err := pr.indexSizes(keys)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, size := range sizes {
+			if size == 0 {
+				return []string{}, 0, nil
+			}
+		}
+		sort.SliceStable(keys, func(i, j int) bool { return sizes[keys[i]] < sizes[keys[j]] })
+	}
+
+	pipe := pr.rc.TxPipeline()
+	for _, u := range unions {
+		pipe.ZUnionStore(u.key, redis.ZStore{Aggregate: "MAX"}, u.keys...)
+		keys = append(keys, u.key)
+	}
+	source := keys[0]
+	if len(keys) > 1 {
+		pipe.ZInterStore(pr.key(queryTmpKey), redis.ZStore{Aggregate: "MAX"}, keys...)
+		source = pr.key(queryTmpKey)
+	}
+	by := redis.ZRangeBy{Min: q.Min, Max: q.Max, Offset: q.Offset, Count: q.Count}
+	var ids *redis.StringSliceCmd
+	if q.Desc {
+		ids = pipe.ZRevRangeByScore(source, by)
+	} else {
+		ids = pipe.ZRangeByScore(source, by)
+	}
+	total := pipe.ZCount(source, q.Min, q.Max)
+	pipe.Del(pr.key(queryTmpKey), pr.key(queryTagsTmpKey), pr.key(queryOwnTmpKey))
+	if _, err := pipe.Exec(); err != nil {
+		return nil, 0, err
+	}
+
+	return ids.Val(), total.Val(), nil
+}
+
+// indexSizes return cardinality of every index
+func (pr *postCache) indexSizes(keys []string) (map[string]int64, error) {
+	pipe := pr.rc.Pipeline()
+	cmds := make([]*redis.IntCmd, 0, len(keys))
+	for _, key := range keys {
+		cmds = append(cmds, pipe.ZCard(key))
+	}
+	if _, err := pipe.Exec(); err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[string]int64, len(keys))
+	for i, key := range keys {
+		sizes[key] = cmds[i].Val()
+	}
+	return sizes, nil
+}
+
+// queryKeys return indexes which are intersected by query and unions of indexes intersected
+// with them: tag indexes when any of tags is enough, published index and index of owner posts
+// when owner sees its unpublished posts
+func (pr *postCache) queryKeys(q IndexQuery) (keys []string, unions []union) {
+	keys = []string{}
+	if q.Name != "" {
+		keys = append(keys, pr.key(nameIndexPrefix+q.Name))
+	}
+	if q.Author != "" {
+		keys = append(keys, pr.key(authorIndexPrefix+q.Author))
+	}
+	tagKeys := make([]string, 0, len(q.Tags))
+	for _, tag := range q.Tags {
+		tagKeys = append(tagKeys, pr.key(tagIndexPrefix+tag))
+	}
+	if !q.AnyTag || len(tagKeys) == 1 {
+		keys = append(keys, tagKeys...)
+	} else if len(tagKeys) > 0 {
+		unions = append(unions, union{key: pr.key(queryTagsTmpKey), keys: tagKeys})
+	}
+	switch {
+	case q.Unpublished:
+		if len(keys) == 0 && len(unions) == 0 {
+			keys = append(keys, pr.key(allIndexKey))
+		}
+	case q.Owner != "":
+		unions = append(unions, union{key: pr.key(queryOwnTmpKey),
+			keys: []string{pr.key(publishedIndexKey), pr.key(ownerIndexPrefix + q.Owner)}})
+	default:
+		keys = append(keys, pr.key(publishedIndexKey))
+	}
+	return keys, unions
+}
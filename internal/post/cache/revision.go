@@ -0,0 +1,23 @@
+package cache
+
+import "github.com/go-redis/redis"
+
+// appendHistory queues appending of revisions to post history keeping at most max latest revisions
+func (pr *postCache) appendHistory(pipe redis.Pipeliner, id string, h History) {
+	if len(h.Revisions) == 0 {
+		return
+	}
+	for _, revision := range h.Revisions {
+		pipe.RPush(pr.key(revisionsPrefix+id), revision)
+	}
+	pipe.LTrim(pr.key(revisionsPrefix+id), -h.Max, -1)
+}
+
+// GetRevisions return stored revisions of post, the oldest first
+func (pr *postCache) GetRevisions(id string) ([]string, error) {
+	resp, err := pr.rc.LRange(pr.key(revisionsPrefix+id), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
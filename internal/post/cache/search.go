@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"unicode/utf8"
+
+	"github.com/go-redis/redis"
+)
+
+// Search return page of post ids matching terms ordered by relevance and total amount of matches
+// Exact term matches weigh twice as much as prefix matches, scores of all terms are summed
+// Only published posts are searched
+func (pr *postCache) Search(terms []string, offset, count int64) ([]string, int64, error) {
+	if len(terms) == 0 {
+		return []string{}, 0, nil
+	}
+	keys := make([]string, 0, 2*len(terms))
+	weights := make([]float64, 0, 2*len(terms))
+	for _, term := range terms {
+		keys = append(keys, pr.key(termKeyPrefix+term), pr.key(prefixKeyPrefix+term))
+		weights = append(weights, 2, 1)
+	}
+
+	// temporary key is safe to share because the whole transaction runs atomically
+	pipe := pr.rc.TxPipeline()
+	pipe.ZUnionStore(pr.key(searchTmpKey), redis.ZStore{Weights: weights, Aggregate: "SUM"}, keys...)
+	pipe.ZInterStore(pr.key(searchPubTmpKey), redis.ZStore{Weights: []float64{1, 0}}, pr.key(searchTmpKey), pr.key(publishedIndexKey))
+	idsCmd := pipe.ZRevRange(pr.key(searchPubTmpKey), offset, offset+count-1)
+	totalCmd := pipe.ZCard(pr.key(searchPubTmpKey))
+	pipe.Del(pr.key(searchTmpKey), pr.key(searchPubTmpKey))
+	if _, err := pipe.Exec(); err != nil {
+		return nil, 0, err
+	}
+
+	return idsCmd.Val(), totalCmd.Val(), nil
+}
+
+// prefixes return all prefixes of term not shorter than minPrefixLen including term itself
+func prefixes(term string) []string {
+	resp := []string{}
+	n := 0
+	for i := range term {
+		if n >= minPrefixLen {
+			resp = append(resp, term[:i])
+		}
+		n++
+	}
+	if utf8.RuneCountInString(term) >= minPrefixLen {
+		resp = append(resp, term)
+	}
+	return resp
+}
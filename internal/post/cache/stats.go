@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"strconv"
+
+	"github.com/go-redis/redis"
+)
+
+// CountPosts return amount of posts in every bucket
+func (pr *postCache) CountPosts(buckets []string) ([]int64, error) {
+	if len(buckets) == 0 {
+		return []int64{}, nil
+	}
+	vals, err := pr.rc.HMGet(pr.key(statsTotalsKey), buckets...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]int64, 0, len(vals))
+	for _, v := range vals {
+		var n int64
+		if s, ok := v.(string); ok {
+			n, _ = strconv.ParseInt(s, 10, 64)
+		}
+		resp = append(resp, n)
+	}
+	return resp, nil
+}
+
+// TopAuthors return authors with the biggest amount of posts summed over buckets
+// Posts field of result holds this sum, Latest field is not filled
+func (pr *postCache) TopAuthors(buckets []string, count int64) ([]Author, error) {
+	if len(buckets) == 0 {
+		return []Author{}, nil
+	}
+	keys := make([]string, 0, len(buckets))
+	for _, bucket := range buckets {
+		keys = append(keys, pr.key(statsKeyPrefix+bucket))
+	}
+
+	// temporary key is safe to share because the whole transaction runs atomically
+	pipe := pr.rc.TxPipeline()
+	pipe.ZUnionStore(pr.key(statsTmpKey), redis.ZStore{Aggregate: "SUM"}, keys...)
+	topCmd := pipe.ZRevRangeByScoreWithScores(pr.key(statsTmpKey), redis.ZRangeBy{Min: "(0", Max: "+inf", Count: count})
+	pipe.Del(pr.key(statsTmpKey))
+	if _, err := pipe.Exec(); err != nil {
+		return nil, err
+	}
+
+	top := topCmd.Val()
+	pipe = pr.rc.Pipeline()
+	names := make([]*redis.StringCmd, 0, len(top))
+	for _, z := range top {
+		names = append(names, pipe.HGet(pr.key(authorKeyPrefix+z.Member.(string)), "name"))
+	}
+	if len(top) > 0 {
+		if _, err := pipe.Exec(); err != nil && err != redis.Nil {
+			return nil, err
+		}
+	}
+
+	authors := make([]Author, 0, len(top))
+	for i, z := range top {
+		key := z.Member.(string)
+		name := names[i].Val()
+		if name == "" {
+			name = key
+		}
+		authors = append(authors, Author{Key: key, Name: name, Posts: int64(z.Score)})
+	}
+	return authors, nil
+}
@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"github.com/go-redis/redis"
+)
+
+// GetTags return page of tags with amount of posts ordered by this amount
+// and total amount of tags
+func (pr *postCache) GetTags(offset, count int64) ([]Tag, int64, error) {
+	pipe := pr.rc.Pipeline()
+	tagsCmd := pipe.ZRevRangeByScoreWithScores(pr.key(tagsKey), redis.ZRangeBy{Min: "(0", Max: "+inf", Offset: offset, Count: count})
+	totalCmd := pipe.ZCount(pr.key(tagsKey), "(0", "+inf")
+	if _, err := pipe.Exec(); err != nil {
+		return nil, 0, err
+	}
+
+	tags := make([]Tag, 0, len(tagsCmd.Val()))
+	for _, z := range tagsCmd.Val() {
+		tags = append(tags, Tag{Name: z.Member.(string), Posts: int64(z.Score)})
+	}
+	return tags, totalCmd.Val(), nil
+}
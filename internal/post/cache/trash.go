@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"strconv"
+
+	"github.com/go-redis/redis"
+)
+
+// TrashPost moves post to trash if its record still equals old: record is replaced by entry,
+// post is removed from all indexes and counters and added to trash and trash of its owner
+// scored by deletion time
+// Return ErrConflict if record was changed meanwhile
+func (pr *postCache) TrashPost(old string, e Entry, deletedAt int64) error {
+	return pr.casPost(e.ID, old, func(pipe redis.Pipeliner) {
+		pr.trash(pipe, e, deletedAt)
+		pr.count(pipe, e, -1)
+	})
+}
+
+// IndexTrash stores trashed post as TrashPost does without changing counters
+// Indexing is idempotent, so trash can be rebuilt from records of trashed posts
+func (pr *postCache) IndexTrash(e Entry, deletedAt int64) error {
+	pipe := pr.rc.TxPipeline()
+	pr.trash(pipe, e, deletedAt)
+	if _, err := pipe.Exec(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// trash queues replacing of post record by entry, removing of post from indexes and adding it to trash
+func (pr *postCache) trash(pipe redis.Pipeliner, e Entry, deletedAt int64) {
+	pipe.Set(pr.key(postKeyPrefix+e.ID), e.Post, 0)
+	pr.unindex(pipe, e)
+	pr.touch(pipe, pr.entryKeys(e)...)
+	byDeletion := redis.Z{Score: float64(deletedAt), Member: e.ID}
+	pipe.ZAdd(pr.key(trashKey), byDeletion)
+	if e.Owner != "" {
+		pipe.ZAdd(pr.key(trashOwnerPrefix+e.Owner), byDeletion)
+		pipe.HSet(pr.key(trashOwnersKey), e.ID, e.Owner)
+	}
+}
+
+// RestorePost takes post out of trash and saves entry back to indexes and counters if its record still equals old
+// Record stays trashed until it is restored or purged, so changed record means post is not in trash anymore
+// and false is returned
+func (pr *postCache) RestorePost(old string, e Entry) (bool, error) {
+	err := pr.casPost(e.ID, old, func(pipe redis.Pipeliner) {
+		pipe.ZRem(pr.key(trashKey), e.ID)
+		if e.Owner != "" {
+			pipe.ZRem(pr.key(trashOwnerPrefix+e.Owner), e.ID)
+			pipe.HDel(pr.key(trashOwnersKey), e.ID)
+		}
+		pipe.Set(pr.key(postKeyPrefix+e.ID), e.Post, 0)
+		pr.index(pipe, e)
+		pr.touch(pipe, pr.entryKeys(e)...)
+		pr.count(pipe, e, 1)
+	})
+	if err == ErrConflict {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetTrash return page of trashed post ids, recently deleted first, and total amount of them
+// Posts of all owners are returned when owner is empty
+func (pr *postCache) GetTrash(owner string, offset, count int64) ([]string, int64, error) {
+	key := pr.key(trashKey)
+	if owner != "" {
+		key = pr.key(trashOwnerPrefix + owner)
+	}
+	pipe := pr.rc.Pipeline()
+	idsCmd := pipe.ZRevRange(key, offset, offset+count-1)
+	totalCmd := pipe.ZCard(key)
+	if _, err := pipe.Exec(); err != nil {
+		return nil, 0, err
+	}
+
+	return idsCmd.Val(), totalCmd.Val(), nil
+}
+
+// PurgeTrash permanently removes at most count posts deleted not later than before
+// together with their revisions and versions and return amount of removed posts
+func (pr *postCache) PurgeTrash(before int64, count int64) (int64, error) {
+	ids, err := pr.rc.ZRangeByScore(pr.key(trashKey), redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(before, 10),
+		Count: count,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, id := range ids {
+		key := pr.key(postKeyPrefix + id)
+		record, err := pr.rc.Get(key).Result()
+		if err != nil && err != redis.Nil {
+			return purged, err
+		}
+		// record is read first, so post restored after the check changes it and is kept by transaction
+		if err := pr.rc.ZScore(pr.key(trashKey), id).Err(); err != nil {
+			if err == redis.Nil {
+				continue
+			}
+			return purged, err
+		}
+		owner, err := pr.rc.HGet(pr.key(trashOwnersKey), id).Result()
+		if err != nil && err != redis.Nil {
+			return purged, err
+		}
+		// record is removed together with trash entries unless it was restored or purged meanwhile
+		err = pr.casPost(id, record, func(pipe redis.Pipeliner) {
+			pipe.ZRem(pr.key(trashKey), id)
+			if owner != "" {
+				pipe.ZRem(pr.key(trashOwnerPrefix+owner), id)
+				pipe.HDel(pr.key(trashOwnersKey), id)
+			}
+			pipe.Del(key, pr.key(revisionsPrefix+id))
+			pipe.HDel(pr.key(versionsKey), key)
+			pipe.HDel(pr.key(modifiedKey), key)
+		})
+		if err == ErrConflict {
+			continue
+		}
+		if err != nil {
+			return purged, err
+		}
+		if record != "" {
+			purged++
+		}
+	}
+	return purged, nil
+}
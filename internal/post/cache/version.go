@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Version is state of indexes or post record: Counter grows on every change
+// and Modified is unix time of the latest change, zero if never changed
+type Version struct {
+	Counter  int64
+	Modified int64
+}
+
+// IndexVersion return combined version of all indexes read by query
+// Published index changes together with indexes of every published post,
+// so it is read only by query without other indexes
+func (pr *postCache) IndexVersion(q IndexQuery) (Version, error) {
+	keys, unions := pr.queryKeys(q)
+	for _, u := range unions {
+		keys = append(keys, u.keys...)
+	}
+	if len(keys) > 1 {
+		filtered := keys[:0]
+		for _, key := range keys {
+			if key != pr.key(publishedIndexKey) {
+				filtered = append(filtered, key)
+			}
+		}
+		keys = filtered
+	}
+	return pr.version(keys)
+}
+
+// PostVersion return version of post record
+func (pr *postCache) PostVersion(id string) (Version, error) {
+	return pr.version([]string{pr.key(postKeyPrefix + id)})
+}
+
+// version sums change counters of keys and picks the latest of their changes
+func (pr *postCache) version(keys []string) (Version, error) {
+	pipe := pr.rc.Pipeline()
+	counters := pipe.HMGet(pr.key(versionsKey), keys...)
+	modified := pipe.HMGet(pr.key(modifiedKey), keys...)
+	if _, err := pipe.Exec(); err != nil {
+		return Version{}, err
+	}
+
+	v := Version{}
+	for i := range keys {
+		v.Counter += parseInt(counters.Val()[i])
+		if m := parseInt(modified.Val()[i]); m > v.Modified {
+			v.Modified = m
+		}
+	}
+	return v, nil
+}
+
+// touch marks keys as changed now, must be queued together with the change itself
+func (pr *postCache) touch(pipe redis.Pipeliner, keys ...string) {
+	now := time.Now().Unix()
+	for _, key := range keys {
+		pipe.HIncrBy(pr.key(versionsKey), key, 1)
+		pipe.HSet(pr.key(modifiedKey), key, now)
+	}
+}
+
+// entryKeys return keys of post record and of indexes which list entry
+func (pr *postCache) entryKeys(e Entry) []string {
+	keys := []string{pr.key(postKeyPrefix + e.ID), pr.key(allIndexKey), pr.key(nameIndexPrefix + e.Name), pr.key(authorIndexPrefix + e.Author)}
+	if e.Owner != "" {
+		keys = append(keys, pr.key(ownerIndexPrefix+e.Owner))
+	}
+	if e.Published {
+		keys = append(keys, pr.key(publishedIndexKey))
+	}
+	for _, tag := range e.Tags {
+		keys = append(keys, pr.key(tagIndexPrefix+tag))
+	}
+	return keys
+}
+
+// parseInt read integer reply of HMGET, missing fields are zero
+func parseInt(v interface{}) int64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
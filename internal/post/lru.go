@@ -0,0 +1,118 @@
+package post
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lru keeps at most max values for ttl, the least recently used value is evicted first
+// Values are added with keys of data they were read from and dropped once any of it changes
+// Every purge or invalidation starts new generation, values loaded during previous generation are not added
+type lru struct {
+	max int
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	gen       uint64
+	order     *list.List
+	entries   map[string]*list.Element
+	deps      map[string]map[string]struct{}
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	deps    []string
+	expires time.Time
+}
+
+func newLRU(max int, ttl time.Duration) *lru {
+	return &lru{max: max, ttl: ttl, now: time.Now, order: list.New(), entries: map[string]*list.Element{},
+		deps: map[string]map[string]struct{}{}}
+}
+
+// get return value of key and current generation, which value loaded on miss is added with
+func (c *lru) get(key string) (interface{}, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*lruEntry)
+		if c.now().Before(e.expires) {
+			c.order.MoveToFront(el)
+			c.hits++
+			return e.value, c.gen, true
+		}
+		c.remove(el)
+	}
+	c.misses++
+	return nil, c.gen, false
+}
+
+// add stores value of key loaded during generation gen from data of deps
+func (c *lru) add(key string, value interface{}, gen uint64, deps []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, deps: deps, expires: c.now().Add(c.ttl)})
+	for _, dep := range deps {
+		if c.deps[dep] == nil {
+			c.deps[dep] = map[string]struct{}{}
+		}
+		c.deps[dep][key] = struct{}{}
+	}
+	for c.order.Len() > c.max {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+// purge removes all values and starts new generation
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.order.Init()
+	c.entries = map[string]*list.Element{}
+	c.deps = map[string]map[string]struct{}{}
+}
+
+// invalidate removes values read from any of deps and starts new generation
+func (c *lru) invalidate(deps []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for _, dep := range deps {
+		for key := range c.deps[dep] {
+			c.remove(c.entries[key])
+		}
+	}
+}
+
+func (c *lru) remove(el *list.Element) {
+	e := el.Value.(*lruEntry)
+	c.order.Remove(el)
+	delete(c.entries, e.key)
+	for _, dep := range e.deps {
+		delete(c.deps[dep], e.key)
+		if len(c.deps[dep]) == 0 {
+			delete(c.deps, dep)
+		}
+	}
+}
+
+// stats return counters of reads, evictions and amount of stored values
+func (c *lru) stats() (hits, misses, evictions uint64, entries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.evictions, c.order.Len()
+}
@@ -0,0 +1,105 @@
+package post
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/PostService/internal/post/cache"
+	"github.com/PostService/model"
+)
+
+// legacyTagPattern matches tag sets replaced by date ordered tag indexes
+const legacyTagPattern = "tag:*"
+
+// Migrate moves posts from per author and per post name lists used by older versions
+// into post records and indexes, then removes the lists, and return amount of imported posts
+// Lists stored by current version, like post revisions, are left untouched
+// Every post was pushed both to its author list and its name list, so post without id
+// is imported once per two copies. Indexes of all stored posts are rebuilt afterwards,
+// trashed posts are indexed in trash only
+func Migrate(c cache.PostCache) (int, error) {
+	s := &service{cache: c, maxRevisions: defaultMaxRevisions}
+	scanned, err := c.ScanKeys("", "list")
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]string, 0, len(scanned))
+	for _, key := range scanned {
+		// revisions are lists too, they belong to stored posts and must be kept
+		if !cache.IsInternalKey(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	copies := map[string]int{}
+	order := []string{}
+	for _, key := range keys {
+		resList, err := c.GetList(key)
+		if err != nil {
+			return 0, err
+		}
+		for _, v := range resList {
+			post := model.Post{}
+			if err := json.Unmarshal([]byte(v), &post); err != nil {
+				return 0, err
+			}
+			if post.ID != "" {
+				// the post has a record already, it is indexed below
+				continue
+			}
+			if copies[v] == 0 {
+				order = append(order, v)
+			}
+			copies[v]++
+		}
+	}
+
+	imported := 0
+	for _, v := range order {
+		post := model.Post{}
+		if err := json.Unmarshal([]byte(v), &post); err != nil {
+			return imported, err
+		}
+		for i := 0; i < (copies[v]+1)/2; i++ {
+			if err := s.InsertPost(post, model.Viewer{}); err != nil {
+				return imported, err
+			}
+			imported++
+		}
+	}
+
+	ids, err := c.PostIDs()
+	if err != nil {
+		return imported, err
+	}
+	sort.Strings(ids)
+	resList, err := c.GetPosts(ids)
+	if err != nil {
+		return imported, err
+	}
+	for _, v := range resList {
+		post := model.Post{}
+		if err := json.Unmarshal([]byte(v), &post); err != nil {
+			return imported, err
+		}
+		if post.DeletedAt != nil {
+			// trashed posts stay out of indexes, trash of their authors is filled
+			if err := c.IndexTrash(newEntry(post, v), post.DeletedAt.Unix()); err != nil {
+				return imported, err
+			}
+			continue
+		}
+		if err := c.SavePost(newEntry(post, v)); err != nil {
+			return imported, err
+		}
+	}
+
+	tagKeys, err := c.ScanKeys(legacyTagPattern, "set")
+	if err != nil {
+		return imported, err
+	}
+	if err := c.DeleteKeys(append(keys, tagKeys...)...); err != nil {
+		return imported, err
+	}
+	return imported, nil
+}
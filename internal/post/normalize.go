@@ -0,0 +1,32 @@
+package post
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeKey return canonical form of author or post name used as storage key:
+// surrounding and repeated whitespace is removed, text is NFC normalized and case folded
+// so "Alice", "alice" and " ALICE " share the same key
+func NormalizeKey(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = norm.NFC.String(s)
+	// folding may decompose characters, so result is composed once more
+	return norm.NFC.String(cases.Fold().String(s))
+}
+
+// normalizeTags return unique normalized tags
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	resp := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		key := NormalizeKey(tag)
+		if key != "" && !seen[key] {
+			seen[key] = true
+			resp = append(resp, key)
+		}
+	}
+	return resp
+}
@@ -2,79 +2,235 @@ package post
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/PostService/infrastructure/breaker"
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/internal/authz"
 	"github.com/PostService/internal/post/cache"
 	"github.com/PostService/model"
 )
 
+// ErrUnavailable returned while storage is unhealthy and requests to it fail fast
+var ErrUnavailable = breaker.ErrOpen
+
 // Service is interface for post logic
 type Service interface {
-	InsertPost(post model.Post) error
-	GetPostsByKey(key string) ([]model.Post, error)
-	GetPostsByNameAndAuthor(name, author string) ([]model.Post, error)
+	InsertPost(post model.Post, actor model.Viewer) error
+	GetPost(id string, viewer model.Viewer) (model.Post, error)
+	UpdatePost(id string, post model.Post, actor model.Viewer, version int64) (model.Post, error)
+	Find(q model.Query) ([]model.Post, int64, error)
+	Version(q model.Query) (model.Version, error)
+	PostVersion(id string) (model.Version, error)
+	GetRevisions(id string, viewer model.Viewer) ([]model.Revision, error)
+	GetRevision(id string, number int64, viewer model.Viewer) (model.Revision, error)
+	DiffRevisions(id string, from, to int64, viewer model.Viewer) ([]model.FieldChange, error)
+	PublishPost(id string, actor model.Viewer, version int64) (model.Post, error)
+	UnpublishPost(id string, actor model.Viewer, version int64) (model.Post, error)
+	PublishScheduled(now time.Time) (int64, error)
+	DeletePost(id string, actor model.Viewer, version int64) error
+	RestorePost(id string, actor model.Viewer) error
+	GetTrash(offset, limit int64, viewer model.Viewer) ([]model.Post, int64, error)
+	PurgeTrash(before time.Time) (int64, error)
+	GetTags(offset, limit int64) ([]model.Tag, int64, error)
+	SearchPosts(query string, offset, limit int64) ([]model.Post, int64, error)
+	GetAuthors(prefix string, offset, limit int64) ([]model.Author, int64, error)
+	GetStats(q model.StatsQuery) ([]model.Stat, error)
 }
 
 // NewPostService return realization of Service interface using cache
-func NewPostService(cache cache.PostCache) Service {
-	return &service{cache}
+func NewPostService(cache cache.PostCache, conf config.PostsConfig) Service {
+	maxRevisions := conf.MaxRevisions
+	if maxRevisions <= 0 {
+		maxRevisions = defaultMaxRevisions
+	}
+	return &service{cache: cache, maxRevisions: maxRevisions, requireVersion: conf.RequireIfMatch}
 }
 
 // service is realization of the post business logic
 type service struct {
-	cache cache.PostCache
+	cache          cache.PostCache
+	maxRevisions   int64
+	requireVersion bool
 }
 
-// InsertPost use cache for storing post object
-func (s *service) InsertPost(post model.Post) error {
-	nameKey := post.Name
-	authorKey := post.Author
-	postBytes, err := json.Marshal(post)
+// InsertPost use cache for storing post object created and owned by actor,
+// post without author is written on behalf of actor
+func (s *service) InsertPost(post model.Post, actor model.Viewer) error {
+	if strings.TrimSpace(post.Author) == "" {
+		post.Author = actor.Name
+	}
+	post.AuthorID = actor.ID
+	if err := validatePost(post); err != nil {
+		return err
+	}
+	if err := authorize(actor, authz.ActionCreate, signs(post.Author, actor)); err != nil {
+		return err
+	}
+	id, err := s.cache.NextID()
 	if err != nil {
 		return err
 	}
-
-	if err := s.cache.InsertPost(nameKey, string(postBytes)); err != nil {
+	post.ID = id
+	post.Revision = 1
+	post.Status = resolveStatus(post, time.Now())
+	postBytes, err := json.Marshal(post)
+	if err != nil {
 		return err
 	}
-	if err := s.cache.InsertPost(authorKey, string(postBytes)); err != nil {
+	rev, err := revision(post, editorName(actor, post), nil)
+	if err != nil {
 		return err
 	}
-	return nil
+
+	return s.cache.CreatePost(newEntry(post, string(postBytes)), s.history(rev))
 }
 
-// GetPostsByKey return posts by key(author or post name)
-func (s *service) GetPostsByKey(key string) ([]model.Post, error) {
-	resList, err := s.cache.GetPostsByKey(key)
+// GetPost return post by id, posts in trash and posts viewer may not see are not found
+func (s *service) GetPost(id string, viewer model.Viewer) (model.Post, error) {
+	post, _, err := s.getPost(id)
 	if err != nil {
-		return nil, err
+		return model.Post{}, err
 	}
-	postList := []model.Post{}
+	if post.DeletedAt != nil || !visible(post, viewer) {
+		return model.Post{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return post, nil
+}
+
+// GetTags return page of tags ordered by amount of posts and total amount of tags
+func (s *service) GetTags(offset, limit int64) ([]model.Tag, int64, error) {
+	resList, total, err := s.cache.GetTags(offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	tags := make([]model.Tag, 0, len(resList))
 	for _, v := range resList {
-		post := model.Post{}
-		if err := json.Unmarshal([]byte(v), &post); err != nil {
+		tags = append(tags, model.Tag{Name: v.Name, Posts: v.Posts})
+	}
+	return tags, total, nil
+}
+
+// SearchPosts return page of posts which names match query words ordered by relevance
+// and total amount of matched posts
+func (s *service) SearchPosts(query string, offset, limit int64) ([]model.Post, int64, error) {
+	ids, total, err := s.cache.Search(Tokenize(query), offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	resList, err := s.cache.GetPosts(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	postList, err := decodePosts(resList)
+	if err != nil {
+		return nil, 0, err
+	}
+	return postList, total, nil
+}
+
+// GetAuthors return page of known authors which names start with prefix
+// and total amount of such authors
+func (s *service) GetAuthors(prefix string, offset, limit int64) ([]model.Author, int64, error) {
+	resList, total, err := s.cache.GetAuthors(NormalizeKey(prefix), offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	authors := make([]model.Author, 0, len(resList))
+	for _, v := range resList {
+		authors = append(authors, model.Author{
+			Name:       v.Name,
+			Posts:      v.Posts,
+			LatestPost: time.Unix(v.Latest, 0).UTC(),
+		})
+	}
+	return authors, total, nil
+}
+
+// GetStats return amount of posts grouped by time buckets or top authors by amount of posts
+func (s *service) GetStats(q model.StatsQuery) ([]model.Stat, error) {
+	if err := validateStatsQuery(q); err != nil {
+		return nil, err
+	}
+	if q.Group == model.GroupAuthor {
+		buckets := []string{allTimeBucket}
+		if !q.From.IsZero() {
+			var err error
+			if buckets, err = coverBuckets(q.From, q.To); err != nil {
+				return nil, err
+			}
+		}
+		authors, err := s.cache.TopAuthors(buckets, q.Top)
+		if err != nil {
 			return nil, err
 		}
-		postList = append(postList, post)
+		stats := make([]model.Stat, 0, len(authors))
+		for _, a := range authors {
+			stats = append(stats, model.Stat{Key: a.Name, Posts: a.Posts})
+		}
+		return stats, nil
 	}
-	return postList, nil
-}
 
-// GetPostsByNameAndAuthor return posts by author and post name
-func (s *service) GetPostsByNameAndAuthor(name, author string) ([]model.Post, error) {
-	resList, err := s.cache.GetPostsByKey(name)
+	labels, buckets, err := timeBuckets(q.Group, q.From, q.To)
 	if err != nil {
 		return nil, err
 	}
+	counts, err := s.cache.CountPosts(buckets)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]model.Stat, 0, len(labels))
+	for i, label := range labels {
+		stats = append(stats, model.Stat{Key: label, Posts: counts[i]})
+	}
+	return stats, nil
+}
 
+// getPost return stored post by id with its stored record
+func (s *service) getPost(id string) (model.Post, string, error) {
+	resList, err := s.cache.GetPosts([]string{id})
+	if err != nil {
+		return model.Post{}, "", err
+	}
+	if len(resList) == 0 {
+		return model.Post{}, "", fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	post := model.Post{}
+	if err := json.Unmarshal([]byte(resList[0]), &post); err != nil {
+		return model.Post{}, "", err
+	}
+	return post, resList[0], nil
+}
+
+// newEntry return stored form of post with keys of indexes it belongs to
+func newEntry(post model.Post, blob string) cache.Entry {
+	return cache.Entry{
+		ID:         post.ID,
+		Post:       blob,
+		Date:       post.Date.Unix(),
+		Name:       NormalizeKey(post.Name),
+		Author:     NormalizeKey(post.Author),
+		AuthorName: strings.TrimSpace(post.Author),
+		Owner:      post.AuthorID,
+		Tags:       normalizeTags(post.Tags),
+		Terms:      Tokenize(post.Name),
+		Buckets:    postBuckets(post.Date),
+		Published:  isPublished(post),
+		Scheduled:  post.Status == model.StatusScheduled,
+	}
+}
+
+// decodePosts unmarshal stored post objects
+func decodePosts(resList []string) ([]model.Post, error) {
 	postList := []model.Post{}
 	for _, v := range resList {
 		post := model.Post{}
 		if err := json.Unmarshal([]byte(v), &post); err != nil {
 			return nil, err
 		}
-		if post.Author == author {
-			postList = append(postList, post)
-		}
+		postList = append(postList, post)
 	}
 	return postList, nil
 }
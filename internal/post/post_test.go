@@ -2,43 +2,73 @@ package post
 
 import (
 	"errors"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/internal/authz"
+	"github.com/PostService/internal/post/cache"
 	"github.com/PostService/mocks"
 	"github.com/PostService/model"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
 
+// zeroBuckets are stat buckets of posts without date
+var zeroBuckets = []string{"all", "day:0001-01-01", "week:0001-W01", "month:0001-01"}
+
 func TestInsertPost(t *testing.T) {
-	t.Run("insert post with name error", func(t *testing.T) {
+	t.Run("post exceeds limits", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		s := NewPostService(mocks.NewMockPostCache(mockCtrl), config.PostsConfig{})
+		tags := make([]string, MaxTags+1)
+		for i := range tags {
+			tags[i] = "tag"
+		}
+		err := s.InsertPost(model.Post{Name: "name1", Author: "author1", Tags: tags}, model.Viewer{})
+		assert.True(t, errors.Is(err, ErrInvalidPost))
+		assert.EqualError(t, err, "invalid post: post has 21 tags, limit is 20")
+	})
+	t.Run("next id error", func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		defer mockCtrl.Finish()
 
 		cacheMock := mocks.NewMockPostCache(mockCtrl)
-		post := model.Post{Name: "name1", Author: "author1"}
-		postString := `{"post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1"}`
-		key := "name1"
-		payloadErr := errors.New("insert error")
-		cacheMock.EXPECT().InsertPost(key, postString).Return(payloadErr)
+		payloadErr := errors.New("id error")
+		cacheMock.EXPECT().NextID().Return("", payloadErr)
 
-		s := NewPostService(cacheMock)
-		err := s.InsertPost(post)
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		err := s.InsertPost(model.Post{Name: "name1", Author: "author1"}, model.Viewer{})
 		assert.Equal(t, payloadErr, err)
 	})
-	t.Run("insert post with author error", func(t *testing.T) {
+	t.Run("save post error", func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		defer mockCtrl.Finish()
 
 		cacheMock := mocks.NewMockPostCache(mockCtrl)
-		post := model.Post{Name: "name1", Author: "author1"}
-		postString := `{"post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1"}`
-		payloadErr := errors.New("insert error")
-		cacheMock.EXPECT().InsertPost(post.Name, postString).Return(nil)
-		cacheMock.EXPECT().InsertPost(post.Author, postString).Return(payloadErr)
+		post := model.Post{Name: "Name1", Author: " Author1", Tags: []string{"Go"}}
+		postString := `{"id":"1","post_name":"Name1","date":"0001-01-01T00:00:00Z","author":" Author1","tags":["Go"],"status":"published","revision":1}`
+		payloadErr := errors.New("save error")
+		cacheMock.EXPECT().NextID().Return("1", nil)
+		cacheMock.EXPECT().CreatePost(cache.Entry{
+			ID:         "1",
+			Post:       postString,
+			Date:       post.Date.Unix(),
+			Name:       "name1",
+			Author:     "author1",
+			AuthorName: "Author1",
+			Tags:       []string{"go"},
+			Terms:      []string{"name1"},
+			Buckets:    zeroBuckets,
+			Published:  true,
+		}, gomock.Any()).Return(payloadErr)
 
-		s := NewPostService(cacheMock)
-		err := s.InsertPost(post)
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		err := s.InsertPost(post, model.Viewer{})
 		assert.Equal(t, payloadErr, err)
 	})
 	t.Run("success", func(t *testing.T) {
@@ -47,115 +77,1268 @@ func TestInsertPost(t *testing.T) {
 
 		cacheMock := mocks.NewMockPostCache(mockCtrl)
 		post := model.Post{Name: "name1", Author: "author1"}
-		postString := `{"post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1"}`
-		cacheMock.EXPECT().InsertPost(post.Name, postString).Return(nil)
-		cacheMock.EXPECT().InsertPost(post.Author, postString).Return(nil)
+		postString := `{"id":"1","post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1","status":"published","revision":1}`
+		cacheMock.EXPECT().NextID().Return("1", nil)
+		cacheMock.EXPECT().CreatePost(cache.Entry{
+			ID:         "1",
+			Post:       postString,
+			Date:       post.Date.Unix(),
+			Name:       "name1",
+			Author:     "author1",
+			AuthorName: "author1",
+			Tags:       []string{},
+			Terms:      []string{"name1"},
+			Buckets:    zeroBuckets,
+			Published:  true,
+		}, gomock.Any()).DoAndReturn(func(e cache.Entry, h cache.History) error {
+			assert.Equal(t, int64(defaultMaxRevisions), h.Max)
+			assert.Len(t, h.Revisions, 1)
+			assert.Contains(t, h.Revisions[0], `"revision":1,"editor":"author1"`)
+			assert.Contains(t, h.Revisions[0], `"post":`+postString)
+			return nil
+		})
 
-		s := NewPostService(cacheMock)
-		err := s.InsertPost(post)
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		err := s.InsertPost(post, model.Viewer{})
 		assert.Equal(t, nil, err)
 	})
 }
 
-func TestGetPostsByKey(t *testing.T) {
-	t.Run("get posts by key error", func(t *testing.T) {
+func TestValidatePost(t *testing.T) {
+	long := func(n int) string { return strings.Repeat("a", n) }
+	var testCases = []struct {
+		name  string
+		post  model.Post
+		valid bool
+	}{
+		{name: "only required fields", post: model.Post{Name: "name1"}, valid: true},
+		{name: "rich post", post: model.Post{Summary: long(MaxSummaryLen), Body: long(MaxBodyLen), Tags: []string{"go"},
+			Language: "en-US", Metadata: map[string]string{"k": long(MaxMetadataValueLen)}}, valid: true},
+		{name: "long summary", post: model.Post{Summary: long(MaxSummaryLen + 1)}},
+		{name: "long body", post: model.Post{Body: long(MaxBodyLen + 1)}},
+		{name: "long language", post: model.Post{Language: long(MaxLanguageLen + 1)}},
+		{name: "empty tag", post: model.Post{Tags: []string{""}}},
+		{name: "long tag", post: model.Post{Tags: []string{long(MaxTagLen + 1)}}},
+		{name: "empty metadata key", post: model.Post{Metadata: map[string]string{"": "v"}}},
+		{name: "long metadata value", post: model.Post{Metadata: map[string]string{"k": long(MaxMetadataValueLen + 1)}}},
+		{name: "draft", post: model.Post{Status: model.StatusDraft}, valid: true},
+		{name: "unknown status", post: model.Post{Status: "hidden"}},
+		{name: "search author", post: model.Post{Author: " Search "}},
+		{name: "numeric author", post: model.Post{Author: "42"}},
+		{name: "author with digits", post: model.Post{Author: "user42"}, valid: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePost(tc.post)
+			if tc.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrInvalidPost))
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	t.Run("invalid query", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		s := NewPostService(mocks.NewMockPostCache(mockCtrl), config.PostsConfig{})
+		posts, total, err := s.Find(Query{Sort: "name", Limit: 10})
+		assert.True(t, errors.Is(err, ErrInvalidQuery))
+		assert.Nil(t, posts)
+		assert.Equal(t, int64(0), total)
+	})
+	t.Run("find error", func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		defer mockCtrl.Finish()
 
 		cacheMock := mocks.NewMockPostCache(mockCtrl)
-		key := "key"
-		payloadErr := errors.New("get error")
-		cacheMock.EXPECT().GetPostsByKey(key).Return(nil, payloadErr)
+		payloadErr := errors.New("find error")
+		cacheMock.EXPECT().FindPosts(gomock.Any()).Return(nil, int64(0), payloadErr)
 
-		s := NewPostService(cacheMock)
-		posts, err := s.GetPostsByKey(key)
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		posts, _, err := s.Find(Query{Name: "name1", Limit: 10})
 		assert.Equal(t, payloadErr, err)
-		assert.Equal(t, posts == nil, true)
+		assert.Nil(t, posts)
 	})
 	t.Run("unmarshal error", func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		defer mockCtrl.Finish()
 
 		cacheMock := mocks.NewMockPostCache(mockCtrl)
-		key := "key"
-		cacheMock.EXPECT().GetPostsByKey(key).Return(
-			[]string{`{"post_name":"name1","date":"0001`},
-			nil,
-		)
+		cacheMock.EXPECT().FindPosts(gomock.Any()).Return([]string{"1"}, int64(1), nil)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{`{"post_name":"name1","date":"0001`}, nil)
 
-		s := NewPostService(cacheMock)
-		posts, err := s.GetPostsByKey(key)
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		posts, _, err := s.Find(Query{Name: "name1", Limit: 10})
 		assert.Equal(t, "unexpected end of JSON input", err.Error())
-		assert.Equal(t, posts == nil, true)
+		assert.Nil(t, posts)
 	})
 	t.Run("success", func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		defer mockCtrl.Finish()
 
 		cacheMock := mocks.NewMockPostCache(mockCtrl)
-		key := "name1"
-		post := model.Post{Name: "name1", Author: "author1"}
-		cacheMock.EXPECT().GetPostsByKey(key).Return(
-			[]string{`{"post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1"}`},
+		from := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		post := model.Post{ID: "2", Name: "Name1", Author: "Author1", Tags: []string{"Go", "Redis"}}
+		cacheMock.EXPECT().FindPosts(cache.IndexQuery{
+			Name:   "name1",
+			Author: "author1",
+			Tags:   []string{"go", "redis"},
+			AnyTag: true,
+			Min:    strconv.FormatInt(from.Unix(), 10),
+			Max:    "+inf",
+			Offset: 20,
+			Count:  10,
+		}).Return([]string{"2"}, int64(21), nil)
+		cacheMock.EXPECT().GetPosts([]string{"2"}).Return(
+			[]string{`{"id":"2","post_name":"Name1","date":"0001-01-01T00:00:00Z","author":"Author1","tags":["Go","Redis"]}`},
 			nil,
 		)
 
-		s := NewPostService(cacheMock)
-		posts, err := s.GetPostsByKey(key)
-		assert.Nil(t, err)
-		assert.NotEmpty(t, posts)
-		assert.Equal(t, posts[0], post)
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		posts, total, err := s.Find(Query{
+			Name:   " name1",
+			Author: "AUTHOR1",
+			Tags:   []string{"Go", "redis", " go"},
+			AnyTag: true,
+			From:   from,
+			Sort:   model.SortDateAsc,
+			Offset: 20,
+			Limit:  10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(21), total)
+		assert.Equal(t, []model.Post{post}, posts)
 	})
 }
 
-func TestGetPostsByNameAndAuthor(t *testing.T) {
-	t.Run("get posts by key error", func(t *testing.T) {
+func TestVersion(t *testing.T) {
+	t.Run("invalid query", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		s := NewPostService(mocks.NewMockPostCache(mockCtrl), config.PostsConfig{})
+		_, err := s.Version(Query{Sort: "name", Limit: 10})
+		assert.True(t, errors.Is(err, ErrInvalidQuery))
+	})
+	t.Run("success", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		modified := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+		cacheMock.EXPECT().IndexVersion(cache.IndexQuery{Author: "alice", Tags: []string{}, Min: "-inf", Max: "+inf", Desc: true, Count: 10}).
+			Return(cache.Version{Counter: 4, Modified: modified.Unix()}, nil)
+		cacheMock.EXPECT().PostVersion("1").Return(cache.Version{}, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		v, err := s.Version(Query{Author: " Alice", Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, model.Version{Counter: 4, Modified: modified}, v)
+		v, err = s.PostVersion("1")
+		assert.NoError(t, err)
+		assert.Equal(t, model.Version{}, v)
+	})
+}
+
+func TestValidateQuery(t *testing.T) {
+	day := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	var testCases = []struct {
+		name  string
+		query Query
+		valid bool
+	}{
+		{name: "no filters", query: Query{Limit: 1}, valid: true},
+		{name: "single day range", query: Query{From: day, To: day, Sort: model.SortDateDesc, Limit: 1}, valid: true},
+		{name: "reversed range", query: Query{From: day, To: day.AddDate(0, 0, -1), Limit: 1}},
+		{name: "unknown sort", query: Query{Sort: "name", Limit: 1}},
+		{name: "negative offset", query: Query{Offset: -1, Limit: 1}},
+		{name: "zero limit", query: Query{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateQuery(tc.query)
+			if tc.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrInvalidQuery))
+		})
+	}
+}
+
+func TestGetTags(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	cacheMock := mocks.NewMockPostCache(mockCtrl)
+	cacheMock.EXPECT().GetTags(int64(0), int64(2)).Return([]cache.Tag{{Name: "go", Posts: 3}, {Name: "redis", Posts: 1}}, int64(5), nil)
+
+	s := NewPostService(cacheMock, config.PostsConfig{})
+	tags, total, err := s.GetTags(0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, []model.Tag{{Name: "go", Posts: 3}, {Name: "redis", Posts: 1}}, tags)
+}
+
+func TestGetAuthors(t *testing.T) {
+	t.Run("get authors error", func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		defer mockCtrl.Finish()
 
 		cacheMock := mocks.NewMockPostCache(mockCtrl)
-		name := "name1"
 		payloadErr := errors.New("get error")
-		cacheMock.EXPECT().GetPostsByKey(name).Return(nil, payloadErr)
+		cacheMock.EXPECT().GetAuthors("al", int64(0), int64(10)).Return(nil, int64(0), payloadErr)
 
-		s := NewPostService(cacheMock)
-		posts, err := s.GetPostsByNameAndAuthor(name, "")
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		authors, total, err := s.GetAuthors("Al", 0, 10)
 		assert.Equal(t, payloadErr, err)
-		assert.Equal(t, posts == nil, true)
+		assert.Nil(t, authors)
+		assert.Equal(t, int64(0), total)
 	})
-	t.Run("unmarshal error", func(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		defer mockCtrl.Finish()
 
 		cacheMock := mocks.NewMockPostCache(mockCtrl)
-		name := "name1"
-		cacheMock.EXPECT().GetPostsByKey(name).Return(
-			[]string{`{"post_name":"name1","date":"0001`},
+		latest := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+		cacheMock.EXPECT().GetAuthors("", int64(5), int64(1)).Return(
+			[]cache.Author{{Key: "alice", Name: "Alice", Posts: 3, Latest: latest.Unix()}},
+			int64(6),
 			nil,
 		)
 
-		s := NewPostService(cacheMock)
-		posts, err := s.GetPostsByNameAndAuthor(name, "")
-		assert.Equal(t, "unexpected end of JSON input", err.Error())
-		assert.Equal(t, posts == nil, true)
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		authors, total, err := s.GetAuthors("", 5, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6), total)
+		assert.Equal(t, []model.Author{{Name: "Alice", Posts: 3, LatestPost: latest}}, authors)
+	})
+}
+
+func TestSearchPosts(t *testing.T) {
+	t.Run("search error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		payloadErr := errors.New("search error")
+		cacheMock.EXPECT().Search([]string{"intro", "to"}, int64(0), int64(10)).Return(nil, int64(0), payloadErr)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		posts, total, err := s.SearchPosts("Intro to", 0, 10)
+		assert.Equal(t, payloadErr, err)
+		assert.Nil(t, posts)
+		assert.Equal(t, int64(0), total)
 	})
 	t.Run("success", func(t *testing.T) {
 		mockCtrl := gomock.NewController(t)
 		defer mockCtrl.Finish()
 
 		cacheMock := mocks.NewMockPostCache(mockCtrl)
-		name := "name1"
-		author := "author1"
-		post := model.Post{Name: "name1", Author: "author1"}
-		cacheMock.EXPECT().GetPostsByKey(name).Return(
-			[]string{`{"post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1"}`},
+		post := model.Post{ID: "7", Name: "Intro to Golang", Author: "author1"}
+		cacheMock.EXPECT().Search([]string{"golang"}, int64(0), int64(10)).Return([]string{"7"}, int64(1), nil)
+		cacheMock.EXPECT().GetPosts([]string{"7"}).Return(
+			[]string{`{"id":"7","post_name":"Intro to Golang","date":"0001-01-01T00:00:00Z","author":"author1"}`},
 			nil,
 		)
 
-		s := NewPostService(cacheMock)
-		posts, err := s.GetPostsByNameAndAuthor(name, author)
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		posts, total, err := s.SearchPosts("GOLANG", 0, 10)
 		assert.Nil(t, err)
-		assert.NotEmpty(t, posts)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, []model.Post{post}, posts)
+	})
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"intro", "to", "golang", "1"}, Tokenize("Intro to  Golang, intro #1"))
+	assert.Empty(t, Tokenize(" -- "))
+}
+
+func TestNormalizeKey(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		key      string
+		expected string
+	}{
+		{name: "case", key: "Alice", expected: "alice"},
+		{name: "spaces", key: "  alice   smith ", expected: "alice smith"},
+		{name: "decomposed", key: "Jose\u0301", expected: "jos\u00e9"},
+		{name: "case folding", key: "STRASSE straße", expected: "strasse strasse"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NormalizeKey(tc.key))
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("scan error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		payloadErr := errors.New("scan error")
+		cacheMock.EXPECT().ScanKeys("", "list").Return(nil, payloadErr)
+
+		imported, err := Migrate(cacheMock)
+		assert.Equal(t, payloadErr, err)
+		assert.Equal(t, 0, imported)
+	})
+	t.Run("success", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		legacy := `{"post_name":"Name1","date":"0001-01-01T00:00:00Z","author":"Alice"}`
+		indexed := `{"id":"7","post_name":"Name2","date":"0001-01-01T00:00:00Z","author":"alice","tags":["Go"]}`
+		cacheMock.EXPECT().ScanKeys("", "list").Return([]string{"Alice", "Name1", "name2", "revisions:7"}, nil)
+		cacheMock.EXPECT().GetList("Alice").Return([]string{legacy, legacy}, nil)
+		cacheMock.EXPECT().GetList("Name1").Return([]string{legacy, legacy}, nil)
+		cacheMock.EXPECT().GetList("name2").Return([]string{indexed}, nil)
+		// two posts with the same content were pushed to both lists
+		cacheMock.EXPECT().NextID().Return("8", nil)
+		cacheMock.EXPECT().NextID().Return("9", nil)
+		cacheMock.EXPECT().CreatePost(gomock.Any(), gomock.Any()).DoAndReturn(func(e cache.Entry, h cache.History) error {
+			assert.Equal(t, "Alice", e.AuthorName)
+			return nil
+		}).Times(2)
+		trashed := `{"id":"6","post_name":"Name3","date":"0001-01-01T00:00:00Z","author":"bob","deleted_at":"2021-02-01T10:00:00Z"}`
+		cacheMock.EXPECT().PostIDs().Return([]string{"7", "6"}, nil)
+		cacheMock.EXPECT().GetPosts([]string{"6", "7"}).Return([]string{trashed, indexed}, nil)
+		// trashed posts are indexed again without being counted
+		cacheMock.EXPECT().IndexTrash(gomock.Any(), time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC).Unix()).
+			DoAndReturn(func(e cache.Entry, deletedAt int64) error {
+				assert.Equal(t, "bob", e.Author)
+				return nil
+			})
+		cacheMock.EXPECT().SavePost(cache.Entry{
+			ID:         "7",
+			Post:       indexed,
+			Date:       time.Time{}.Unix(),
+			Name:       "name2",
+			Author:     "alice",
+			AuthorName: "alice",
+			Tags:       []string{"go"},
+			Terms:      []string{"name2"},
+			Buckets:    zeroBuckets,
+			Published:  true,
+		}).Return(nil)
+		cacheMock.EXPECT().ScanKeys("tag:*", "set").Return([]string{"tag:go"}, nil)
+		cacheMock.EXPECT().DeleteKeys("Alice", "Name1", "name2", "tag:go").Return(nil)
+
+		imported, err := Migrate(cacheMock)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, imported)
+	})
+	t.Run("revisions are kept", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		// neither GetList nor InsertPost is expected for revisions list
+		cacheMock.EXPECT().ScanKeys("", "list").Return([]string{"revisions:1", "{posts}revisions:2"}, nil)
+		cacheMock.EXPECT().PostIDs().Return([]string{}, nil)
+		cacheMock.EXPECT().GetPosts([]string{}).Return([]string{}, nil)
+		cacheMock.EXPECT().ScanKeys("tag:*", "set").Return([]string{}, nil)
+		cacheMock.EXPECT().DeleteKeys().Return(nil)
+
+		imported, err := Migrate(cacheMock)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, imported)
+	})
+}
+
+func TestGetStats(t *testing.T) {
+	t.Run("invalid query", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		s := NewPostService(mocks.NewMockPostCache(mockCtrl), config.PostsConfig{})
+		stats, err := s.GetStats(model.StatsQuery{Group: "year"})
+		assert.True(t, errors.Is(err, ErrInvalidQuery))
+		assert.Nil(t, stats)
+	})
+	t.Run("too many buckets", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		s := NewPostService(mocks.NewMockPostCache(mockCtrl), config.PostsConfig{})
+		stats, err := s.GetStats(model.StatsQuery{
+			Group: model.GroupDay,
+			From:  time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+		assert.Equal(t, ErrTooManyBuckets, err)
+		assert.Nil(t, stats)
+	})
+	t.Run("weeks", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().CountPosts([]string{"week:2020-W52", "week:2020-W53", "week:2021-W01"}).Return([]int64{1, 0, 5}, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		stats, err := s.GetStats(model.StatsQuery{
+			Group: model.GroupWeek,
+			From:  time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC),
+			To:    time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Stat{{Key: "2020-W52", Posts: 1}, {Key: "2020-W53", Posts: 0}, {Key: "2021-W01", Posts: 5}}, stats)
+	})
+	t.Run("count error", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		payloadErr := errors.New("count error")
+		cacheMock.EXPECT().CountPosts([]string{"month:2020-01", "month:2020-02"}).Return(nil, payloadErr)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		stats, err := s.GetStats(model.StatsQuery{
+			Group: model.GroupMonth,
+			From:  time.Date(2020, 1, 25, 0, 0, 0, 0, time.UTC),
+			To:    time.Date(2020, 2, 4, 0, 0, 0, 0, time.UTC),
+		})
+		assert.Equal(t, payloadErr, err)
+		assert.Nil(t, stats)
+	})
+	t.Run("top authors of all time", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().TopAuthors([]string{"all"}, int64(2)).Return([]cache.Author{{Key: "alice", Name: "Alice", Posts: 7}}, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		stats, err := s.GetStats(model.StatsQuery{Group: model.GroupAuthor, Top: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Stat{{Key: "Alice", Posts: 7}}, stats)
+	})
+	t.Run("top authors of window", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().TopAuthors([]string{"day:2020-01-30", "day:2020-01-31", "month:2020-02", "day:2020-03-01"}, int64(3)).
+			Return([]cache.Author{}, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		stats, err := s.GetStats(model.StatsQuery{
+			Group: model.GroupAuthor,
+			From:  time.Date(2020, 1, 30, 0, 0, 0, 0, time.UTC),
+			To:    time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+			Top:   3,
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, stats)
+	})
+}
+
+func TestDeletePost(t *testing.T) {
+	t.Run("post not found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{}, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		err := s.DeletePost("1", model.Viewer{}, VersionAny)
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+	t.Run("post in trash already", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return(
+			[]string{`{"id":"1","post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1","deleted_at":"2021-02-01T10:00:00Z"}`},
+			nil,
+		)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		err := s.DeletePost("1", model.Viewer{}, VersionAny)
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+	t.Run("version required", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return(
+			[]string{`{"id":"1","post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1","revision":2}`},
+			nil,
+		)
+
+		s := NewPostService(cacheMock, config.PostsConfig{RequireIfMatch: true})
+		err := s.DeletePost("1", model.Viewer{}, VersionUnknown)
+		assert.Equal(t, ErrVersionRequired, err)
+	})
+	t.Run("version mismatch", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return(
+			[]string{`{"id":"1","post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1","revision":2}`},
+			nil,
+		)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		err := s.DeletePost("1", model.Viewer{}, 1)
+		assert.EqualError(t, err, "post version does not match: current revision is 2")
+	})
+	t.Run("changed concurrently", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return(
+			[]string{`{"id":"1","post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1"}`},
+			nil,
+		)
+		cacheMock.EXPECT().TrashPost(gomock.Any(), gomock.Any(), gomock.Any()).Return(cache.ErrConflict)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		err := s.DeletePost("1", model.Viewer{}, VersionAny)
+		assert.True(t, errors.Is(err, ErrVersionMismatch))
+	})
+	t.Run("success", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		stored := `{"id":"1","post_name":"Name1","date":"0001-01-01T00:00:00Z","author":"Author1","tags":["Go"],"revision":3}`
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{stored}, nil)
+		cacheMock.EXPECT().TrashPost(stored, gomock.Any(), gomock.Any()).DoAndReturn(func(old string, e cache.Entry, deletedAt int64) error {
+			assert.Equal(t, "author1", e.Author)
+			assert.Equal(t, []string{"go"}, e.Tags)
+			assert.Equal(t, zeroBuckets, e.Buckets)
+			assert.True(t, e.Published)
+			assert.Contains(t, e.Post, `"deleted_at":"`+time.Unix(deletedAt, 0).UTC().Format(time.RFC3339)+`"`)
+			return nil
+		})
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		err := s.DeletePost("1", model.Viewer{}, 3)
+		assert.NoError(t, err)
+	})
+}
+
+func TestRestorePost(t *testing.T) {
+	t.Run("post not in trash", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return(
+			[]string{`{"id":"1","post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1"}`},
+			nil,
+		)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		err := s.RestorePost("1", model.Viewer{})
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+	t.Run("success", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return(
+			[]string{`{"id":"1","post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1","deleted_at":"2021-02-01T10:00:00Z"}`},
+			nil,
+		)
+		cacheMock.EXPECT().RestorePost(`{"id":"1","post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1","deleted_at":"2021-02-01T10:00:00Z"}`, cache.Entry{
+			ID:         "1",
+			Post:       `{"id":"1","post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1"}`,
+			Date:       time.Time{}.Unix(),
+			Name:       "name1",
+			Author:     "author1",
+			AuthorName: "author1",
+			Tags:       []string{},
+			Terms:      []string{"name1"},
+			Buckets:    zeroBuckets,
+			Published:  true,
+		}).Return(true, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		err := s.RestorePost("1", model.Viewer{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestGetTrash(t *testing.T) {
+	trashed := `{"id":"1","post_name":"name1","date":"2021-01-01T00:00:00Z","author":"Alice","author_id":"42","deleted_at":"2021-02-01T10:00:00Z"}`
+	var testCases = []struct {
+		name   string
+		viewer model.Viewer
+		owner  string
+		err    string
+	}{
+		{name: "writer sees own posts", viewer: model.Viewer{ID: "42", Name: "Alice", Roles: []string{authz.RoleWriter}}, owner: "42"},
+		{name: "editor sees own posts", viewer: model.Viewer{ID: "7", Name: "Ed", Roles: []string{authz.RoleEditor}}, owner: "7"},
+		{name: "admin sees all posts", viewer: model.Viewer{ID: "1", Name: "root", Roles: []string{authz.RoleAdmin}}},
+		{name: "authentication disabled", viewer: model.Viewer{}},
+		{name: "unknown role", viewer: model.Viewer{ID: "9", Name: "guest", Roles: []string{"reader"}}, err: "forbidden: guest may not delete posts"},
+		{name: "writer without identity", viewer: model.Viewer{Name: "Alice"}, err: "forbidden: Alice may delete own posts only"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			cacheMock := mocks.NewMockPostCache(mockCtrl)
+			if tc.err == "" {
+				cacheMock.EXPECT().GetTrash(tc.owner, int64(0), int64(10)).Return([]string{"1"}, int64(1), nil)
+				cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{trashed}, nil)
+			}
+
+			s := NewPostService(cacheMock, config.PostsConfig{})
+			posts, total, err := s.GetTrash(0, 10, tc.viewer)
+			if tc.err != "" {
+				assert.True(t, errors.Is(err, ErrForbidden))
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), total)
+			assert.Equal(t, "1", posts[0].ID)
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	cacheMock := mocks.NewMockPostCache(mockCtrl)
+	before := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	cacheMock.EXPECT().PurgeTrash(before.Unix(), int64(purgeBatch)).Return(int64(purgeBatch), nil)
+	cacheMock.EXPECT().PurgeTrash(before.Unix(), int64(purgeBatch)).Return(int64(3), nil)
+
+	s := NewPostService(cacheMock, config.PostsConfig{})
+	purged, err := s.PurgeTrash(before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(purgeBatch+3), purged)
+}
+
+func TestUpdatePost(t *testing.T) {
+	t.Run("post in trash", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return(
+			[]string{`{"id":"1","post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1","deleted_at":"2021-02-01T10:00:00Z"}`},
+			nil,
+		)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		_, err := s.UpdatePost("1", model.Post{Name: "name2", Author: "author1"}, model.Viewer{Name: "author1"}, VersionAny)
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+	t.Run("changed concurrently", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return(
+			[]string{`{"id":"1","post_name":"name1","date":"0001-01-01T00:00:00Z","author":"author1","author_id":"42","revision":2}`},
+			nil,
+		)
+		cacheMock.EXPECT().UpdatePost(gomock.Any(), gomock.Any(), gomock.Any()).Return(cache.ErrConflict)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		_, err := s.UpdatePost("1", model.Post{Name: "name2", Author: "author1"}, model.Viewer{ID: "42", Name: "author1"}, 2)
+		assert.True(t, errors.Is(err, ErrVersionMismatch))
+	})
+	t.Run("success", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		oldString := `{"id":"1","post_name":"Name1","date":"0001-01-01T00:00:00Z","author":"Alice","tags":["Go"],"author_id":"42"}`
+		// post handed over to another author keeps its owner
+		newString := `{"id":"1","post_name":"Name2","date":"0001-01-01T00:00:00Z","author":"Bob","tags":["Go"],"status":"published","author_id":"42","revision":2}`
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{oldString}, nil)
+		cacheMock.EXPECT().UpdatePost(
+			cache.Entry{
+				ID:         "1",
+				Post:       oldString,
+				Date:       time.Time{}.Unix(),
+				Name:       "name1",
+				Author:     "alice",
+				AuthorName: "Alice",
+				Owner:      "42",
+				Tags:       []string{"go"},
+				Terms:      []string{"name1"},
+				Buckets:    zeroBuckets,
+				Published:  true,
+			},
+			cache.Entry{
+				ID:         "1",
+				Post:       newString,
+				Date:       time.Time{}.Unix(),
+				Name:       "name2",
+				Author:     "bob",
+				AuthorName: "Bob",
+				Owner:      "42",
+				Tags:       []string{"go"},
+				Terms:      []string{"name2"},
+				Buckets:    zeroBuckets,
+				Published:  true,
+			},
+			gomock.Any(),
+		).DoAndReturn(func(old, e cache.Entry, h cache.History) error {
+			assert.Equal(t, int64(5), h.Max)
+			assert.Len(t, h.Revisions, 2)
+			// post without revision gets its original version stored first
+			assert.Contains(t, h.Revisions[0], `"revision":1,"editor":"Alice"`)
+			assert.Contains(t, h.Revisions[1], `"revision":2,"editor":"Bob"`)
+			assert.Contains(t, h.Revisions[1], `"changes":["post_name","author"],"post":`+newString)
+			return nil
+		})
+
+		s := NewPostService(cacheMock, config.PostsConfig{MaxRevisions: 5})
+		updated, err := s.UpdatePost("1", model.Post{Name: "Name2", Author: "Bob", AuthorID: "7", Tags: []string{"Go"}},
+			model.Viewer{ID: "7", Name: "Bob", Roles: []string{authz.RoleEditor}}, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), updated.Revision)
+	})
+}
+
+func TestRevisions(t *testing.T) {
+	current := `{"id":"1","post_name":"name1","date":"2021-01-01T00:00:00Z","author":"alice","tags":["go"],"revision":2}`
+	revisions := []string{
+		`{"revision":1,"editor":"alice","created_at":"2021-02-01T10:00:00Z","post":{"id":"1","post_name":"name1","date":"2021-01-01T00:00:00Z","author":"alice","revision":1}}`,
+		`{"revision":2,"editor":"alice","created_at":"2021-02-02T10:00:00Z","changes":["tags"],` +
+			`"post":{"id":"1","post_name":"name1","date":"2021-01-01T00:00:00Z","author":"alice","tags":["go"],"revision":2}}`,
+	}
+	t.Run("latest first", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{current}, nil)
+		cacheMock.EXPECT().GetRevisions("1").Return(revisions, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		revs, err := s.GetRevisions("1", model.Viewer{})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2, 1}, []int64{revs[0].Number, revs[1].Number})
+		assert.Equal(t, []string{"tags"}, revs[0].Changes)
+	})
+	t.Run("missing post", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"2"}).Return([]string{}, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		_, err := s.GetRevisions("2", model.Viewer{})
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+	t.Run("trashed post", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		trashed := `{"id":"1","post_name":"name1","date":"2021-01-01T00:00:00Z","author":"alice","revision":2,"deleted_at":"2021-02-01T10:00:00Z"}`
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{trashed}, nil).Times(3)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		_, err := s.GetRevisions("1", model.Viewer{})
+		assert.True(t, errors.Is(err, ErrNotFound))
+		_, err = s.GetRevision("1", 1, model.Viewer{})
+		assert.True(t, errors.Is(err, ErrNotFound))
+		_, err = s.DiffRevisions("1", 1, 2, model.Viewer{Name: "alice"})
+		assert.True(t, errors.Is(err, ErrNotFound))
+	})
+	t.Run("draft", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		draft := `{"id":"1","post_name":"name1","date":"2021-01-01T00:00:00Z","author":"alice","author_id":"42","status":"draft","revision":2}`
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{draft}, nil).Times(4)
+		cacheMock.EXPECT().GetRevisions("1").Return(revisions, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		_, err := s.GetRevisions("1", model.Viewer{})
+		assert.True(t, errors.Is(err, ErrNotFound))
+		_, err = s.GetRevision("1", 1, model.Viewer{})
+		assert.True(t, errors.Is(err, ErrNotFound))
+		_, err = s.DiffRevisions("1", 1, 2, model.Viewer{})
+		assert.True(t, errors.Is(err, ErrNotFound))
+		revs, err := s.GetRevisions("1", model.Viewer{ID: "42", Name: "Alice"})
+		assert.NoError(t, err)
+		assert.Len(t, revs, 2)
+	})
+	t.Run("missing revision", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{current}, nil)
+		cacheMock.EXPECT().GetRevisions("1").Return(revisions, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		_, err := s.GetRevision("1", 3, model.Viewer{})
+		assert.EqualError(t, err, "post not found: revision 3 of 1")
+	})
+	t.Run("diff", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{current}, nil)
+		cacheMock.EXPECT().GetRevisions("1").Return(revisions, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		changes, err := s.DiffRevisions("1", 1, 2, model.Viewer{})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.FieldChange{{Field: "tags", From: []string{}, To: []string{"go"}}}, changes)
+	})
+}
+
+func TestDiffPosts(t *testing.T) {
+	a := model.Post{Name: "name1", Date: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Metadata: map[string]string{}}
+	b := model.Post{Name: "name1", Date: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), Summary: "summary"}
+	assert.Equal(t, []model.FieldChange{
+		{Field: "date", From: "01.01.21", To: "02.01.21"},
+		{Field: "summary", From: "", To: "summary"},
+	}, diffPosts(a, b))
+}
+
+func TestPublishScheduled(t *testing.T) {
+	t.Run("insert unpublished", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		future := time.Now().AddDate(0, 0, 2).Truncate(24 * time.Hour)
+		cacheMock.EXPECT().NextID().Return("1", nil)
+		// unpublished posts are created uncounted by authors, statistics and tags
+		cacheMock.EXPECT().CreatePost(gomock.Any(), gomock.Any()).DoAndReturn(func(e cache.Entry, h cache.History) error {
+			assert.False(t, e.Published)
+			assert.True(t, e.Scheduled)
+			assert.Contains(t, e.Post, `"status":"scheduled"`)
+			return nil
+		})
+		cacheMock.EXPECT().NextID().Return("2", nil)
+		cacheMock.EXPECT().CreatePost(gomock.Any(), gomock.Any()).DoAndReturn(func(e cache.Entry, h cache.History) error {
+			assert.False(t, e.Published)
+			assert.False(t, e.Scheduled)
+			assert.Contains(t, e.Post, `"status":"draft"`)
+			return nil
+		})
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		assert.NoError(t, s.InsertPost(model.Post{Name: "name1", Author: "alice", Date: future}, model.Viewer{}))
+		assert.NoError(t, s.InsertPost(model.Post{Name: "name2", Author: "alice", Status: model.StatusDraft}, model.Viewer{}))
+	})
+	t.Run("publish due posts", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		now := time.Date(2021, 1, 2, 0, 0, 1, 0, time.UTC)
+		due := `{"id":"1","post_name":"name1","date":"2021-01-02T00:00:00Z","author":"alice","status":"scheduled","revision":1}`
+		changed := `{"id":"2","post_name":"name2","date":"2021-01-01T00:00:00Z","author":"alice","status":"published","revision":2}`
+		cacheMock.EXPECT().DuePosts(now.Unix(), int64(publishBatch)).Return([]string{"1", "2"}, nil)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{due}, nil).Times(2)
+		cacheMock.EXPECT().GetPosts([]string{"2"}).Return([]string{changed}, nil)
+		cacheMock.EXPECT().UpdatePost(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(old, e cache.Entry, h cache.History) error {
+			assert.True(t, old.Scheduled)
+			assert.False(t, old.Published)
+			assert.True(t, e.Published)
+			assert.False(t, e.Scheduled)
+			assert.Equal(t, now.Truncate(24*time.Hour).Unix(), e.Date)
+			assert.Len(t, h.Revisions, 1)
+			assert.Contains(t, h.Revisions[0], `"revision":2,"editor":"scheduler","created_at":`)
+			assert.Contains(t, h.Revisions[0], `"changes":["status"]`)
+			return nil
+		})
+		cacheMock.EXPECT().Notify(`{"type":"published","id":"1","time":"2021-01-02T00:00:01Z"}`).Return(nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		published, err := s.PublishScheduled(now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), published)
+	})
+}
+
+func TestTransitions(t *testing.T) {
+	t.Run("publish published post", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return(
+			[]string{`{"id":"1","post_name":"name1","date":"2021-01-01T00:00:00Z","author":"alice","author_id":"42","revision":2}`}, nil)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		_, err := s.PublishPost("1", model.Viewer{ID: "42", Name: "alice"}, 2)
+		assert.True(t, errors.Is(err, ErrInvalidTransition))
+		assert.EqualError(t, err, "invalid post status transition: can not publish published post")
+	})
+	t.Run("publish draft dated in the future", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		date := time.Now().UTC().AddDate(0, 0, 2).Truncate(24 * time.Hour)
+		draft := `{"id":"1","post_name":"name1","date":"` + date.Format(time.RFC3339) + `","author":"alice","status":"draft","revision":2}`
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{draft}, nil).Times(2)
+		cacheMock.EXPECT().UpdatePost(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(old, e cache.Entry, h cache.History) error {
+			assert.False(t, e.Published)
+			assert.True(t, e.Scheduled)
+			assert.Len(t, h.Revisions, 1)
+			assert.Contains(t, h.Revisions[0], `"revision":3,"editor":"alice"`)
+			return nil
+		})
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		post, err := s.PublishPost("1", model.Viewer{}, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, model.StatusScheduled, post.Status)
+	})
+	t.Run("unpublish post", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		published := `{"id":"1","post_name":"name1","date":"2021-01-01T00:00:00Z","author":"alice","status":"published","revision":2}`
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{published}, nil).Times(2)
+		cacheMock.EXPECT().UpdatePost(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(old, e cache.Entry, h cache.History) error {
+			assert.True(t, old.Published)
+			assert.False(t, e.Published)
+			assert.Contains(t, e.Post, `"status":"draft"`)
+			return nil
+		})
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		post, err := s.UnpublishPost("1", model.Viewer{Name: "editor", Roles: []string{authz.RoleEditor}}, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, model.StatusDraft, post.Status)
+		assert.Equal(t, int64(3), post.Revision)
+	})
+}
+
+func TestVisibility(t *testing.T) {
+	alice := model.Viewer{ID: "42", Name: " Alice"}
+	var testCases = []struct {
+		name        string
+		query       Query
+		unpublished bool
+		owner       string
+		forbidden   bool
+	}{
+		{name: "anonymous", query: Query{Author: "alice"}},
+		{name: "own posts", query: Query{Author: "alice", Viewer: alice}, owner: "42"},
+		{name: "own unpublished posts", query: Query{Author: "alice", Viewer: alice, Unpublished: true}, owner: "42"},
+		{name: "namesake without identity", query: Query{Author: "alice", Viewer: model.Viewer{Name: "alice"}}},
+		{name: "posts of other author", query: Query{Author: "bob", Viewer: alice}},
+		{name: "unpublished posts of other author", query: Query{Author: "bob", Viewer: alice, Unpublished: true}, forbidden: true},
+		{name: "all unpublished posts", query: Query{Unpublished: true}, forbidden: true},
+		{name: "admin", query: Query{Viewer: model.Viewer{ID: "1", Name: "root", Roles: []string{authz.RoleAdmin}}, Unpublished: true}, unpublished: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := visibleQuery(tc.query)
+			if tc.forbidden {
+				assert.True(t, errors.Is(err, ErrForbidden))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.unpublished, q.Unpublished)
+			assert.Equal(t, tc.owner, q.Owner)
+		})
+	}
+
+	t.Run("draft by id", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		draft := `{"id":"1","post_name":"name1","date":"2021-01-01T00:00:00Z","author":"alice","author_id":"42","status":"draft","revision":1}`
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{draft}, nil).Times(3)
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		_, err := s.GetPost("1", model.Viewer{})
+		assert.True(t, errors.Is(err, ErrNotFound))
+		// author name does not tell the owner
+		_, err = s.GetPost("1", model.Viewer{ID: "43", Name: "Alice"})
+		assert.True(t, errors.Is(err, ErrNotFound))
+		post, err := s.GetPost("1", alice)
+		assert.NoError(t, err)
+		assert.Equal(t, model.StatusDraft, post.Status)
+	})
+}
+
+func TestAuthorization(t *testing.T) {
+	alice := model.Viewer{ID: "42", Name: "Alice", Roles: []string{authz.RoleWriter}}
+	t.Run("create post of other author", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		s := NewPostService(mocks.NewMockPostCache(mockCtrl), config.PostsConfig{})
+		err := s.InsertPost(model.Post{Name: "name1", Author: "bob"}, alice)
+		assert.True(t, errors.Is(err, ErrForbidden))
+		assert.EqualError(t, err, "forbidden: Alice may create own posts only")
+	})
+	t.Run("author derived from actor", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		cacheMock.EXPECT().NextID().Return("1", nil)
+		cacheMock.EXPECT().CreatePost(gomock.Any(), gomock.Any()).DoAndReturn(func(e cache.Entry, h cache.History) error {
+			assert.Equal(t, "alice", e.Author)
+			assert.Equal(t, "Alice", e.AuthorName)
+			assert.Equal(t, "42", e.Owner)
+			assert.Contains(t, e.Post, `"author_id":"42"`)
+			return nil
+		})
+
+		s := NewPostService(cacheMock, config.PostsConfig{})
+		// owner is the actor whatever client sends
+		assert.NoError(t, s.InsertPost(model.Post{Name: "name1", AuthorID: "7"}, alice))
+	})
+
+	bobPost := `{"id":"1","post_name":"name1","date":"2021-01-01T00:00:00Z","author":"bob","author_id":"7","status":"draft","revision":2}`
+	var testCases = []struct {
+		name   string
+		action func(s Service) error
+		err    string
+	}{
+		{
+			name: "update post of other author",
+			action: func(s Service) error {
+				_, err := s.UpdatePost("1", model.Post{Name: "name2", Author: "bob"}, alice, VersionAny)
+				return err
+			},
+			err: "forbidden: Alice may update own posts only",
+		},
+		{
+			name: "update post by namesake of author",
+			action: func(s Service) error {
+				_, err := s.UpdatePost("1", model.Post{Name: "name2", Author: "bob"}, model.Viewer{ID: "8", Name: "Bob"}, VersionAny)
+				return err
+			},
+			err: "forbidden: Bob may update own posts only",
+		},
+		{
+			name: "hand over post to other author",
+			action: func(s Service) error {
+				_, err := s.UpdatePost("1", model.Post{Name: "name2", Author: "carol"}, model.Viewer{ID: "7", Name: "bob"}, VersionAny)
+				return err
+			},
+			err: "forbidden: bob may update own posts only",
+		},
+		{
+			name: "publish post of other author",
+			action: func(s Service) error {
+				_, err := s.PublishPost("1", alice, VersionAny)
+				return err
+			},
+			err: "forbidden: Alice may publish own posts only",
+		},
+		{
+			name: "delete post of other author by editor",
+			action: func(s Service) error {
+				return s.DeletePost("1", model.Viewer{ID: "9", Name: "ed", Roles: []string{authz.RoleEditor}}, VersionAny)
+			},
+			err: "forbidden: ed may delete own posts only",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			cacheMock := mocks.NewMockPostCache(mockCtrl)
+			cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{bobPost}, nil)
+
+			s := NewPostService(cacheMock, config.PostsConfig{})
+			err := tc.action(s)
+			assert.True(t, errors.Is(err, ErrForbidden))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestLRU(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newLRU(2, 10*time.Second)
+	c.now = func() time.Time { return now }
+
+	_, gen, ok := c.get("a")
+	assert.False(t, ok)
+	c.add("a", 1, gen, []string{"x"})
+	c.add("b", 2, gen, []string{"x"})
+	v, _, ok := c.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	c.add("c", 3, gen, []string{"y"})
+	_, _, ok = c.get("b")
+	assert.False(t, ok, "least recently used value is evicted")
+
+	_, gen, _ = c.get("e")
+	c.invalidate([]string{"x"})
+	_, _, ok = c.get("a")
+	assert.False(t, ok, "value read from invalidated key is dropped")
+	_, _, ok = c.get("c")
+	assert.True(t, ok, "value read from other keys is kept")
+	c.add("e", 5, gen, []string{"z"})
+	_, _, ok = c.get("e")
+	assert.False(t, ok, "value loaded before invalidation is not added")
+
+	now = now.Add(10 * time.Second)
+	_, _, ok = c.get("c")
+	assert.False(t, ok, "expired value is dropped")
+
+	_, gen, _ = c.get("d")
+	c.purge()
+	c.add("d", 4, gen, nil)
+	_, _, ok = c.get("d")
+	assert.False(t, ok, "value loaded before purge is not added")
+
+	hits, misses, evictions, entries := c.stats()
+	assert.Equal(t, []uint64{2, 8, 1}, []uint64{hits, misses, evictions})
+	assert.Equal(t, 0, entries)
+	assert.Empty(t, c.deps)
+}
+
+func TestReadCache(t *testing.T) {
+	q := model.Query{Author: "Alice", Limit: 10}
+	posts := []model.Post{{ID: "1", Author: "Alice"}}
+
+	t.Run("reads are cached until change", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+		svcMock := mocks.NewMockService(mockCtrl)
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		c := NewReadCache(svcMock, cacheMock, mocks.NewMockLogger(mockCtrl), config.ReadCacheConfig{})
+
+		svcMock.EXPECT().Find(q).Return(posts, int64(1), nil).Times(2)
+		svcMock.EXPECT().Version(q).Return(model.Version{Counter: 1}, nil)
+		svcMock.EXPECT().GetPost("1", model.Viewer{}).Return(posts[0], nil)
+		svcMock.EXPECT().GetPost("1", model.Viewer{Name: "Alice"}).Return(posts[0], nil)
+		svcMock.EXPECT().DeletePost("2", model.Viewer{}, int64(1)).Return(nil)
+		svcMock.EXPECT().DeletePost("1", model.Viewer{}, int64(1)).Return(nil)
+		cacheMock.EXPECT().GetPosts([]string{"2"}).Return([]string{`{"id":"2","post_name":"Name2","author":"Bob","tags":["Go"]}`}, nil)
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return([]string{`{"id":"1","post_name":"Name1","author":"Alice"}`}, nil)
+		cacheMock.EXPECT().Invalidate(`{"origin":"` + c.origin + `","keys":["all","name:name2","author:bob","post:2","tag:go"]}`).Return(nil)
+		cacheMock.EXPECT().Invalidate(`{"origin":"` + c.origin + `","keys":["all","name:name1","author:alice","post:1"]}`).Return(nil)
+
+		for i := 0; i < 2; i++ {
+			found, total, err := c.Find(q)
+			assert.NoError(t, err)
+			assert.Equal(t, posts, found)
+			assert.Equal(t, int64(1), total)
+			v, err := c.Version(q)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), v.Counter)
+			_, err = c.GetPost("1", model.Viewer{})
+			assert.NoError(t, err)
+		}
+		_, err := c.GetPost("1", model.Viewer{Name: "Alice"})
+		assert.NoError(t, err, "posts read by other viewer are read again")
+
+		assert.NoError(t, c.DeletePost("2", model.Viewer{}, 1))
+		_, _, err = c.Find(q)
+		assert.NoError(t, err, "posts of other authors are kept")
+		assert.NoError(t, c.DeletePost("1", model.Viewer{}, 1))
+		_, _, err = c.Find(q)
+		assert.NoError(t, err)
+		assert.Equal(t, ReadCacheStats{Hits: 4, Misses: 5, Entries: 1}, c.Stats())
+	})
+	t.Run("errors are not cached", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+		svcMock := mocks.NewMockService(mockCtrl)
+		c := NewReadCache(svcMock, mocks.NewMockPostCache(mockCtrl), mocks.NewMockLogger(mockCtrl), config.ReadCacheConfig{})
+
+		svcMock.EXPECT().GetPost("1", model.Viewer{}).Return(model.Post{}, ErrNotFound)
+		svcMock.EXPECT().GetPost("1", model.Viewer{}).Return(posts[0], nil)
+		_, err := c.GetPost("1", model.Viewer{})
+		assert.Equal(t, ErrNotFound, err)
+		post, err := c.GetPost("1", model.Viewer{})
+		assert.NoError(t, err)
 		assert.Equal(t, posts[0], post)
 	})
+	t.Run("page read during change is not cached", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+		svcMock := mocks.NewMockService(mockCtrl)
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		logMock := mocks.NewMockLogger(mockCtrl)
+		c := NewReadCache(svcMock, cacheMock, logMock, config.ReadCacheConfig{})
+
+		payloadErr := errors.New("publish error")
+		// post which can not be read before change drops all values
+		cacheMock.EXPECT().GetPosts([]string{"1"}).Return(nil, payloadErr)
+		cacheMock.EXPECT().Invalidate(`{"origin":"` + c.origin + `"}`).Return(payloadErr)
+		logMock.EXPECT().Errorf("can not invalidate read cache of other replicas: %v", payloadErr)
+		svcMock.EXPECT().Find(q).DoAndReturn(func(model.Query) ([]model.Post, int64, error) {
+			_, err := c.UpdatePost("1", posts[0], model.Viewer{}, VersionAny)
+			return posts, 1, err
+		})
+		svcMock.EXPECT().UpdatePost("1", posts[0], model.Viewer{}, VersionAny).Return(posts[0], nil)
+		_, _, err := c.Find(q)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, c.Stats().Entries)
+	})
+	t.Run("invalidations of other replicas", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+		svcMock := mocks.NewMockService(mockCtrl)
+		cacheMock := mocks.NewMockPostCache(mockCtrl)
+		c := NewReadCache(svcMock, cacheMock, mocks.NewMockLogger(mockCtrl), config.ReadCacheConfig{})
+
+		stop := make(chan struct{})
+		msgs := make(chan string)
+		cacheMock.EXPECT().Invalidations(gomock.Any()).Return((<-chan string)(msgs), nil)
+		svcMock.EXPECT().PostVersion("1").Return(model.Version{Counter: 1}, nil).Times(2)
+		svcMock.EXPECT().PostVersion("2").Return(model.Version{Counter: 1}, nil).Times(2)
+		done := make(chan struct{})
+		go func() {
+			c.Run(stop)
+			close(done)
+		}()
+
+		// values are dropped when subscription starts, so they are read once it is received from
+		msgs <- `{"origin":"replica","keys":["post:3"]}`
+		for _, id := range []string{"1", "2"} {
+			_, err := c.PostVersion(id)
+			assert.NoError(t, err)
+		}
+		msgs <- `{"origin":"` + c.origin + `","keys":["post:2"]}`
+		msgs <- `{"origin":"replica","keys":["post:1"]}`
+		// invalidation is handled once the next one is received
+		msgs <- `{"origin":"replica","keys":["post:3"]}`
+		_, err := c.PostVersion("1")
+		assert.NoError(t, err)
+		_, err = c.PostVersion("2")
+		assert.NoError(t, err, "values of other keys are kept")
+		// replicas of older versions drop all values
+		msgs <- "replica"
+		msgs <- `{"origin":"` + c.origin + `"}`
+		_, err = c.PostVersion("2")
+		assert.NoError(t, err)
+		close(stop)
+		<-done
+		assert.Equal(t, uint64(4), c.Stats().Invalidations)
+	})
 }
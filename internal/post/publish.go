@@ -0,0 +1,128 @@
+package post
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/PostService/infrastructure/logger"
+	"github.com/PostService/internal/authz"
+	"github.com/PostService/model"
+)
+
+// publishBatch is amount of due posts published per cache call
+const publishBatch = 100
+
+// schedulerActor is actor publishing scheduled posts of all authors and editor of revisions it makes
+var schedulerActor = model.Viewer{Name: "scheduler", Roles: []string{authz.RoleAdmin}}
+
+// resolveStatus return status of post saved at now: drafts stay drafts,
+// other posts are scheduled until their date and published since then
+func resolveStatus(post model.Post, now time.Time) string {
+	if post.Status == model.StatusDraft {
+		return model.StatusDraft
+	}
+	if post.Date.After(now) {
+		return model.StatusScheduled
+	}
+	return model.StatusPublished
+}
+
+// isPublished reports whether post is listed publicly, posts stored before
+// statuses were introduced have no status and are published
+func isPublished(post model.Post) bool {
+	return post.Status == "" || post.Status == model.StatusPublished
+}
+
+// PublishScheduled publishes scheduled posts dated not later than now, notifies
+// subscribers about every of them and return amount of published posts
+// Post changed concurrently is left scheduled until the next call
+func (s *service) PublishScheduled(now time.Time) (int64, error) {
+	var published int64
+	for {
+		ids, err := s.cache.DuePosts(now.Unix(), publishBatch)
+		if err != nil {
+			return published, err
+		}
+		var n int64
+		for _, id := range ids {
+			ok, err := s.publish(id, now)
+			if err != nil {
+				return published, err
+			}
+			if ok {
+				n++
+			}
+		}
+		published += n
+		// skipped posts are due again, so only progress makes the next batch worth reading
+		if len(ids) < publishBatch || n == 0 {
+			return published, nil
+		}
+	}
+}
+
+// publish publishes scheduled post and return false if post was changed meanwhile
+func (s *service) publish(id string, now time.Time) (bool, error) {
+	post, _, err := s.getPost(id)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if post.Status != model.StatusScheduled {
+		return false, nil
+	}
+	post.Status = model.StatusPublished
+	_, err = s.update(id, post, schedulerActor, post.Revision, now)
+	if errors.Is(err, ErrVersionMismatch) || errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	event, err := json.Marshal(model.PostEvent{Type: model.EventPublished, ID: id, Time: now.UTC()})
+	if err != nil {
+		return true, err
+	}
+	return true, s.cache.Notify(string(event))
+}
+
+// Scheduler periodically publishes scheduled posts which date has come
+type Scheduler struct {
+	svc      Service
+	log      logger.Logger
+	interval time.Duration
+}
+
+// NewScheduler return Scheduler publishing due posts every interval
+func NewScheduler(svc Service, log logger.Logger, interval time.Duration) *Scheduler {
+	return &Scheduler{svc: svc, log: log, interval: interval}
+}
+
+// Run publishes due posts right away and then every interval until stop is closed
+func (sc *Scheduler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(sc.interval)
+	defer ticker.Stop()
+	for {
+		sc.publish()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (sc *Scheduler) publish() {
+	published, err := sc.svc.PublishScheduled(time.Now())
+	if err != nil {
+		sc.log.Error(err.Error())
+		return
+	}
+	if published > 0 {
+		sc.log.Printf("%d scheduled posts published", published)
+	}
+}
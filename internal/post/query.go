@@ -0,0 +1,79 @@
+package post
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/PostService/internal/post/cache"
+	"github.com/PostService/model"
+)
+
+// Query describes filters, order and page of posts returned by Service.Find
+// It is declared in model, so mocks of Service do not depend on this package
+type Query = model.Query
+
+// validateQuery checks that posts query can be executed
+func validateQuery(q Query) error {
+	switch q.Sort {
+	case "", model.SortDateDesc, model.SortDateAsc:
+	default:
+		return fmt.Errorf("%w: unknown sort %q", ErrInvalidQuery, q.Sort)
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return fmt.Errorf("%w: date range ends before it starts", ErrInvalidQuery)
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidQuery)
+	}
+	if q.Limit <= 0 {
+		return fmt.Errorf("%w: limit must be positive", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// indexQuery translates posts query into lookup of cache indexes
+func indexQuery(q Query) cache.IndexQuery {
+	iq := cache.IndexQuery{
+		Name:        NormalizeKey(q.Name),
+		Author:      NormalizeKey(q.Author),
+		Tags:        normalizeTags(q.Tags),
+		AnyTag:      q.AnyTag,
+		Min:         "-inf",
+		Max:         "+inf",
+		Desc:        q.Sort != model.SortDateAsc,
+		Offset:      q.Offset,
+		Count:       q.Limit,
+		Unpublished: q.Unpublished,
+	}
+	if !q.From.IsZero() {
+		iq.Min = strconv.FormatInt(q.From.Unix(), 10)
+	}
+	if !q.To.IsZero() {
+		iq.Max = strconv.FormatInt(q.To.Unix(), 10)
+	}
+	return iq
+}
+
+// Find return page of posts matching query which viewer may see and total amount of matched posts
+func (s *service) Find(q Query) ([]model.Post, int64, error) {
+	if err := validateQuery(q); err != nil {
+		return nil, 0, err
+	}
+	iq, err := visibleQuery(q)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids, total, err := s.cache.FindPosts(iq)
+	if err != nil {
+		return nil, 0, err
+	}
+	resList, err := s.cache.GetPosts(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	postList, err := decodePosts(resList)
+	if err != nil {
+		return nil, 0, err
+	}
+	return postList, total, nil
+}
@@ -0,0 +1,382 @@
+package post
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/infrastructure/logger"
+	"github.com/PostService/internal/post/cache"
+	"github.com/PostService/model"
+)
+
+const (
+	// defaultReadCacheEntries is amount of reads kept by ReadCache when it is not configured
+	defaultReadCacheEntries = 10000
+	// defaultReadCacheTTL is how long reads are kept by ReadCache when it is not configured
+	defaultReadCacheTTL = 30 * time.Second
+	// readCacheStatsInterval is how often ReadCache statistics are logged
+	readCacheStatsInterval = time.Minute
+	// resubscribeInterval is delay of subscribing again to invalidations which can not be received
+	resubscribeInterval = 5 * time.Second
+	// allPosts is read dependency of reads of posts without filters, every change affects them
+	allPosts = "all"
+)
+
+// ReadCacheStats counts reads served by ReadCache
+type ReadCacheStats struct {
+	Hits          uint64
+	Misses        uint64
+	Evictions     uint64
+	Invalidations uint64
+	Entries       int
+}
+
+// ReadCache is Service keeping posts, pages of posts and their versions read from next in process
+// Values are kept with keys of post and of index filters they were read from, which are dropped
+// once posts of these keys are changed through ReadCache or by other replicas, which are told
+// about them by invalidations sent through pc. Values read by viewers are kept apart, as they
+// may see different posts. Returned values are shared by all readers
+type ReadCache struct {
+	next    Service
+	pc      cache.PostCache
+	log     logger.Logger
+	entries *lru
+	// origin tells invalidations sent by this replica apart
+	origin        string
+	invalidations uint64
+}
+
+// invalidation is message telling replicas that posts of keys were changed by replica origin,
+// all posts were changed when keys are empty
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys,omitempty"`
+}
+
+// findResult is page of posts returned by Find
+type findResult struct {
+	posts []model.Post
+	total int64
+}
+
+// NewReadCache return ReadCache of next reads, invalidations are received only while Run runs
+func NewReadCache(next Service, pc cache.PostCache, log logger.Logger, conf config.ReadCacheConfig) *ReadCache {
+	max := conf.MaxEntries
+	if max <= 0 {
+		max = defaultReadCacheEntries
+	}
+	ttl := time.Duration(conf.TTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = defaultReadCacheTTL
+	}
+	origin := make([]byte, 8)
+	rand.Read(origin)
+	return &ReadCache{next: next, pc: pc, log: log, entries: newLRU(max, ttl), origin: hex.EncodeToString(origin)}
+}
+
+// Run drops values once other replicas change posts and logs statistics until stop is closed
+// Changes made while invalidations can not be received are missed, so values are dropped
+// whenever subscription starts
+func (c *ReadCache) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(readCacheStatsInterval)
+	defer ticker.Stop()
+	var (
+		msgs  <-chan string
+		retry <-chan time.Time
+	)
+	subscribe := func() {
+		var err error
+		if msgs, err = c.pc.Invalidations(stop); err != nil {
+			c.log.Errorf("can not subscribe to invalidations of read cache: %v", err)
+			retry = time.After(resubscribeInterval)
+			return
+		}
+		retry = nil
+		c.entries.purge()
+	}
+	subscribe()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-retry:
+			subscribe()
+		case msg, ok := <-msgs:
+			if !ok {
+				msgs, retry = nil, time.After(resubscribeInterval)
+				continue
+			}
+			inv := invalidation{}
+			if err := json.Unmarshal([]byte(msg), &inv); err != nil {
+				// replicas of older versions send their origin only
+				inv = invalidation{Origin: msg}
+			}
+			if inv.Origin == c.origin {
+				continue
+			}
+			if len(inv.Keys) == 0 {
+				c.entries.purge()
+			} else {
+				c.entries.invalidate(inv.Keys)
+			}
+			atomic.AddUint64(&c.invalidations, 1)
+		case <-ticker.C:
+			c.logStats()
+		}
+	}
+}
+
+// Stats return statistics of reads served by ReadCache
+func (c *ReadCache) Stats() ReadCacheStats {
+	hits, misses, evictions, entries := c.entries.stats()
+	return ReadCacheStats{
+		Hits:          hits,
+		Misses:        misses,
+		Evictions:     evictions,
+		Invalidations: atomic.LoadUint64(&c.invalidations),
+		Entries:       entries,
+	}
+}
+
+func (c *ReadCache) logStats() {
+	s := c.Stats()
+	if s.Hits+s.Misses == 0 {
+		return
+	}
+	c.log.Printf("read cache: %d hits, %d misses, %d evictions, %d invalidations, %d entries",
+		s.Hits, s.Misses, s.Evictions, s.Invalidations, s.Entries)
+}
+
+// invalidate drops values read from keys on this replica and tells other replicas to drop theirs,
+// all values are dropped when keys are nil
+func (c *ReadCache) invalidate(keys []string) {
+	if keys == nil {
+		c.entries.purge()
+	} else {
+		c.entries.invalidate(keys)
+	}
+	msg, err := json.Marshal(invalidation{Origin: c.origin, Keys: keys})
+	if err == nil {
+		err = c.pc.Invalidate(string(msg))
+	}
+	if err != nil {
+		c.log.Errorf("can not invalidate read cache of other replicas: %v", err)
+	}
+}
+
+// stored return keys of post as it is stored now, nil if they can not be read
+func (c *ReadCache) stored(id string) []string {
+	resList, err := c.pc.GetPosts([]string{id})
+	if err != nil || len(resList) == 0 {
+		return nil
+	}
+	post := model.Post{}
+	if err := json.Unmarshal([]byte(resList[0]), &post); err != nil {
+		return nil
+	}
+	return postKeys(post)
+}
+
+// postKeys return keys of reads post is part of: the post itself, listings of all posts and
+// listings filtered by its name, author and tags
+func postKeys(post model.Post) []string {
+	keys := []string{allPosts, "name:" + NormalizeKey(post.Name), "author:" + NormalizeKey(post.Author)}
+	if post.ID != "" {
+		keys = append(keys, "post:"+post.ID)
+	}
+	for _, tag := range normalizeTags(post.Tags) {
+		keys = append(keys, "tag:"+tag)
+	}
+	return keys
+}
+
+// queryKeys return keys of reads query is answered from, any post matched by query
+// has at least one of them, query without filters reads all posts
+func queryKeys(q model.Query) []string {
+	keys := []string{}
+	if q.Name != "" {
+		keys = append(keys, "name:"+NormalizeKey(q.Name))
+	}
+	if q.Author != "" {
+		keys = append(keys, "author:"+NormalizeKey(q.Author))
+	}
+	for _, tag := range normalizeTags(q.Tags) {
+		keys = append(keys, "tag:"+tag)
+	}
+	if len(keys) == 0 {
+		keys = append(keys, allPosts)
+	}
+	return keys
+}
+
+// changed return keys of post before and after change, nil if post before change is not known
+func changed(before []string, after model.Post) []string {
+	if before == nil {
+		return nil
+	}
+	return append(before, postKeys(after)...)
+}
+
+// readKey return key of read of kind with its arguments
+func readKey(kind string, args ...interface{}) string {
+	b, _ := json.Marshal(args)
+	return kind + string(b)
+}
+
+// GetPost return post viewer may see
+func (c *ReadCache) GetPost(id string, viewer model.Viewer) (model.Post, error) {
+	key := readKey("post", id, viewer)
+	v, gen, ok := c.entries.get(key)
+	if ok {
+		return v.(model.Post), nil
+	}
+	post, err := c.next.GetPost(id, viewer)
+	if err != nil {
+		return model.Post{}, err
+	}
+	c.entries.add(key, post, gen, []string{"post:" + id})
+	return post, nil
+}
+
+// Find return page of posts matching query which viewer may see
+func (c *ReadCache) Find(q model.Query) ([]model.Post, int64, error) {
+	key := readKey("find", q)
+	v, gen, ok := c.entries.get(key)
+	if ok {
+		res := v.(findResult)
+		return res.posts, res.total, nil
+	}
+	posts, total, err := c.next.Find(q)
+	if err != nil {
+		return nil, 0, err
+	}
+	c.entries.add(key, findResult{posts: posts, total: total}, gen, queryKeys(q))
+	return posts, total, nil
+}
+
+// Version return version of posts matched by query
+// Versions are kept together with posts, so they describe posts served by ReadCache
+func (c *ReadCache) Version(q model.Query) (model.Version, error) {
+	return c.version(readKey("version", q), queryKeys(q), func() (model.Version, error) { return c.next.Version(q) })
+}
+
+// PostVersion return version of post
+func (c *ReadCache) PostVersion(id string) (model.Version, error) {
+	return c.version(readKey("postVersion", id), []string{"post:" + id}, func() (model.Version, error) { return c.next.PostVersion(id) })
+}
+
+func (c *ReadCache) version(key string, deps []string, load func() (model.Version, error)) (model.Version, error) {
+	v, gen, ok := c.entries.get(key)
+	if ok {
+		return v.(model.Version), nil
+	}
+	version, err := load()
+	if err != nil {
+		return model.Version{}, err
+	}
+	c.entries.add(key, version, gen, deps)
+	return version, nil
+}
+
+// InsertPost stores post and drops values of its keys on all replicas
+func (c *ReadCache) InsertPost(post model.Post, actor model.Viewer) error {
+	if strings.TrimSpace(post.Author) == "" {
+		post.Author = actor.Name
+	}
+	defer c.invalidate(postKeys(post))
+	return c.next.InsertPost(post, actor)
+}
+
+// UpdatePost replaces post and drops values of its old and new keys on all replicas
+func (c *ReadCache) UpdatePost(id string, post model.Post, actor model.Viewer, version int64) (model.Post, error) {
+	// keys of post are read before it is changed
+	post.ID = id
+	keys := changed(c.stored(id), post)
+	defer c.invalidate(keys)
+	return c.next.UpdatePost(id, post, actor, version)
+}
+
+// PublishPost publishes post and drops values of its keys on all replicas
+func (c *ReadCache) PublishPost(id string, actor model.Viewer, version int64) (model.Post, error) {
+	keys := c.stored(id)
+	defer c.invalidate(keys)
+	return c.next.PublishPost(id, actor, version)
+}
+
+// UnpublishPost turns post into draft and drops values of its keys on all replicas
+func (c *ReadCache) UnpublishPost(id string, actor model.Viewer, version int64) (model.Post, error) {
+	keys := c.stored(id)
+	defer c.invalidate(keys)
+	return c.next.UnpublishPost(id, actor, version)
+}
+
+// PublishScheduled publishes due posts and drops values of all replicas if any was published
+func (c *ReadCache) PublishScheduled(now time.Time) (int64, error) {
+	published, err := c.next.PublishScheduled(now)
+	if published > 0 || err != nil {
+		c.invalidate(nil)
+	}
+	return published, err
+}
+
+// DeletePost moves post to trash and drops values of its keys on all replicas
+func (c *ReadCache) DeletePost(id string, actor model.Viewer, version int64) error {
+	keys := c.stored(id)
+	defer c.invalidate(keys)
+	return c.next.DeletePost(id, actor, version)
+}
+
+// RestorePost restores post from trash and drops values of its keys on all replicas
+func (c *ReadCache) RestorePost(id string, actor model.Viewer) error {
+	keys := c.stored(id)
+	defer c.invalidate(keys)
+	return c.next.RestorePost(id, actor)
+}
+
+// PurgeTrash removes posts from trash and drops values of all replicas if any was removed
+func (c *ReadCache) PurgeTrash(before time.Time) (int64, error) {
+	purged, err := c.next.PurgeTrash(before)
+	if purged > 0 || err != nil {
+		c.invalidate(nil)
+	}
+	return purged, err
+}
+
+// reads below are served by next without caching
+
+func (c *ReadCache) GetRevisions(id string, viewer model.Viewer) ([]model.Revision, error) {
+	return c.next.GetRevisions(id, viewer)
+}
+
+func (c *ReadCache) GetRevision(id string, number int64, viewer model.Viewer) (model.Revision, error) {
+	return c.next.GetRevision(id, number, viewer)
+}
+
+func (c *ReadCache) DiffRevisions(id string, from, to int64, viewer model.Viewer) ([]model.FieldChange, error) {
+	return c.next.DiffRevisions(id, from, to, viewer)
+}
+
+func (c *ReadCache) GetTrash(offset, limit int64, viewer model.Viewer) ([]model.Post, int64, error) {
+	return c.next.GetTrash(offset, limit, viewer)
+}
+
+func (c *ReadCache) GetTags(offset, limit int64) ([]model.Tag, int64, error) {
+	return c.next.GetTags(offset, limit)
+}
+
+func (c *ReadCache) SearchPosts(query string, offset, limit int64) ([]model.Post, int64, error) {
+	return c.next.SearchPosts(query, offset, limit)
+}
+
+func (c *ReadCache) GetAuthors(prefix string, offset, limit int64) ([]model.Author, int64, error) {
+	return c.next.GetAuthors(prefix, offset, limit)
+}
+
+func (c *ReadCache) GetStats(q model.StatsQuery) ([]model.Stat, error) {
+	return c.next.GetStats(q)
+}
@@ -0,0 +1,209 @@
+package post
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/PostService/internal/authz"
+	"github.com/PostService/internal/post/cache"
+	"github.com/PostService/model"
+)
+
+// defaultMaxRevisions is amount of revisions kept per post when it is not configured
+const defaultMaxRevisions = 50
+
+// UpdatePost replaces content of existing post and appends its revision made by actor
+// Post is updated only if its current revision matches expected version
+func (s *service) UpdatePost(id string, post model.Post, actor model.Viewer, version int64) (model.Post, error) {
+	return s.update(id, post, actor, version, time.Now())
+}
+
+// update replaces post as UpdatePost does, status of post is resolved at now
+func (s *service) update(id string, post model.Post, actor model.Viewer, version int64, now time.Time) (model.Post, error) {
+	if err := validatePost(post); err != nil {
+		return model.Post{}, err
+	}
+	old, blob, err := s.getPost(id)
+	if err != nil {
+		return model.Post{}, err
+	}
+	if old.DeletedAt != nil {
+		return model.Post{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err := authorize(actor, authz.ActionUpdate, owns(old, actor)); err != nil {
+		return model.Post{}, err
+	}
+	// post handed over to another author name must be editable by actor on behalf of it,
+	// owner of post stays the same
+	if NormalizeKey(post.Author) != NormalizeKey(old.Author) {
+		if err := authorize(actor, authz.ActionUpdate, signs(post.Author, actor)); err != nil {
+			return model.Post{}, err
+		}
+	}
+	post.AuthorID = old.AuthorID
+	if err := s.checkVersion(old, version); err != nil {
+		return model.Post{}, err
+	}
+	oldE := newEntry(old, blob)
+	revisions := []string{}
+	if old.Revision == 0 {
+		// post stored before revisions were introduced, its original version becomes the first revision
+		old.Revision = 1
+		rev, err := revision(old, strings.TrimSpace(old.Author), nil)
+		if err != nil {
+			return model.Post{}, err
+		}
+		revisions = append(revisions, rev)
+	}
+	post.ID = id
+	post.Revision = old.Revision + 1
+	if post.Status == "" {
+		// post keeps its status unless new one is requested
+		post.Status = old.Status
+	}
+	post.Status = resolveStatus(post, now)
+	postBytes, err := json.Marshal(post)
+	if err != nil {
+		return model.Post{}, err
+	}
+	changes := []string{}
+	for _, c := range diffPosts(old, post) {
+		changes = append(changes, c.Field)
+	}
+	rev, err := revision(post, editorName(actor, post), changes)
+	if err != nil {
+		return model.Post{}, err
+	}
+	revisions = append(revisions, rev)
+
+	// record, counters and revisions are changed in one transaction
+	if err := s.cache.UpdatePost(oldE, newEntry(post, string(postBytes)), s.history(revisions...)); err != nil {
+		return model.Post{}, conflictErr(err)
+	}
+	return post, nil
+}
+
+// revision return encoded revision of post made by editor
+func revision(post model.Post, editor string, changes []string) (string, error) {
+	rev := model.Revision{
+		Number:  post.Revision,
+		Editor:  editor,
+		Created: time.Now().UTC().Truncate(time.Second),
+		Changes: changes,
+		Post:    post,
+	}
+	revBytes, err := json.Marshal(rev)
+	if err != nil {
+		return "", err
+	}
+	return string(revBytes), nil
+}
+
+// history return revisions stored together with post, keeping as many revisions as configured
+func (s *service) history(revisions ...string) cache.History {
+	return cache.History{Revisions: revisions, Max: s.maxRevisions}
+}
+
+// GetRevisions return stored revisions of post, the latest first
+// Revisions of trashed posts and of posts viewer may not see are not found
+func (s *service) GetRevisions(id string, viewer model.Viewer) ([]model.Revision, error) {
+	post, _, err := s.getPost(id)
+	if err != nil {
+		return nil, err
+	}
+	if post.DeletedAt != nil || !visible(post, viewer) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	resList, err := s.cache.GetRevisions(id)
+	if err != nil {
+		return nil, err
+	}
+	revs := make([]model.Revision, len(resList))
+	for i, v := range resList {
+		if err := json.Unmarshal([]byte(v), &revs[len(resList)-1-i]); err != nil {
+			return nil, err
+		}
+	}
+	return revs, nil
+}
+
+// GetRevision return revision of post by its number
+func (s *service) GetRevision(id string, number int64, viewer model.Viewer) (model.Revision, error) {
+	revs, err := s.GetRevisions(id, viewer)
+	if err != nil {
+		return model.Revision{}, err
+	}
+	return findRevision(id, revs, number)
+}
+
+// DiffRevisions return fields changed between two revisions of post
+func (s *service) DiffRevisions(id string, from, to int64, viewer model.Viewer) ([]model.FieldChange, error) {
+	revs, err := s.GetRevisions(id, viewer)
+	if err != nil {
+		return nil, err
+	}
+	fromRev, err := findRevision(id, revs, from)
+	if err != nil {
+		return nil, err
+	}
+	toRev, err := findRevision(id, revs, to)
+	if err != nil {
+		return nil, err
+	}
+	return diffPosts(fromRev.Post, toRev.Post), nil
+}
+
+// findRevision return revision of post by its number
+func findRevision(id string, revs []model.Revision, number int64) (model.Revision, error) {
+	for _, rev := range revs {
+		if rev.Number == number {
+			return rev, nil
+		}
+	}
+	return model.Revision{}, fmt.Errorf("%w: revision %d of %s", ErrNotFound, number, id)
+}
+
+// diffPosts return changed fields of post in order of their appearance in responses
+func diffPosts(a, b model.Post) []model.FieldChange {
+	changes := []model.FieldChange{}
+	add := func(field string, from, to interface{}) {
+		if !reflect.DeepEqual(from, to) {
+			changes = append(changes, model.FieldChange{Field: field, From: from, To: to})
+		}
+	}
+	add("post_name", a.Name, b.Name)
+	add("date", a.Date.Format(model.DateFormat), b.Date.Format(model.DateFormat))
+	add("author", a.Author, b.Author)
+	add("summary", a.Summary, b.Summary)
+	add("body", a.Body, b.Body)
+	add("tags", nonNilTags(a.Tags), nonNilTags(b.Tags))
+	add("language", a.Language, b.Language)
+	add("metadata", nonNilMetadata(a.Metadata), nonNilMetadata(b.Metadata))
+	add("status", postStatus(a), postStatus(b))
+	return changes
+}
+
+// postStatus return status of post, posts without status are published
+func postStatus(post model.Post) string {
+	if post.Status == "" {
+		return model.StatusPublished
+	}
+	return post.Status
+}
+
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func nonNilMetadata(md map[string]string) map[string]string {
+	if md == nil {
+		return map[string]string{}
+	}
+	return md
+}
@@ -0,0 +1,22 @@
+package post
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize splits text into normalized unique words used as search terms
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(NormalizeKey(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := make(map[string]bool, len(words))
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,127 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PostService/model"
+)
+
+// maxStatBuckets limits amount of time buckets requested at once
+const maxStatBuckets = 1000
+
+// allTimeBucket holds counters for the whole service lifetime
+const allTimeBucket = "all"
+
+// ErrInvalidQuery returned when query parameters can not be satisfied
+var ErrInvalidQuery = errors.New("invalid query")
+
+// ErrTooManyBuckets returned when stats time window is too wide for grouping
+var ErrTooManyBuckets = fmt.Errorf("%w: time window contains more than %d buckets", ErrInvalidQuery, maxStatBuckets)
+
+// validateStatsQuery checks that stats query can be executed
+func validateStatsQuery(q model.StatsQuery) error {
+	switch q.Group {
+	case model.GroupDay, model.GroupWeek, model.GroupMonth:
+		if q.From.IsZero() || q.To.IsZero() {
+			return fmt.Errorf("%w: time window is required for time grouping", ErrInvalidQuery)
+		}
+	case model.GroupAuthor:
+		if q.From.IsZero() != q.To.IsZero() {
+			return fmt.Errorf("%w: both bounds of time window are required", ErrInvalidQuery)
+		}
+		if q.Top <= 0 {
+			return fmt.Errorf("%w: top must be positive", ErrInvalidQuery)
+		}
+	default:
+		return fmt.Errorf("%w: unknown grouping %q", ErrInvalidQuery, q.Group)
+	}
+	if q.To.Before(q.From) {
+		return fmt.Errorf("%w: time window ends before it starts", ErrInvalidQuery)
+	}
+	return nil
+}
+
+func dayBucket(t time.Time) string {
+	return "day:" + t.Format("2006-01-02")
+}
+
+func weekBucket(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("week:%04d-W%02d", year, week)
+}
+
+func monthBucket(t time.Time) string {
+	return "month:" + t.Format("2006-01")
+}
+
+// postBuckets return all stat buckets post with such date is counted in
+func postBuckets(date time.Time) []string {
+	date = date.UTC()
+	return []string{allTimeBucket, dayBucket(date), weekBucket(date), monthBucket(date)}
+}
+
+// truncateDay return midnight of t's day in UTC
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// timeBuckets return labels and buckets of grouping covering [from, to] window
+func timeBuckets(group string, from, to time.Time) (labels []string, buckets []string, err error) {
+	from, to = truncateDay(from), truncateDay(to)
+	var (
+		start  time.Time
+		next   func(time.Time) time.Time
+		bucket func(time.Time) string
+		layout func(time.Time) string
+	)
+	switch group {
+	case model.GroupDay:
+		start = from
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+		bucket = dayBucket
+		layout = func(t time.Time) string { return t.Format("2006-01-02") }
+	case model.GroupWeek:
+		start = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+		bucket = weekBucket
+		layout = func(t time.Time) string { return weekBucket(t)[len("week:"):] }
+	case model.GroupMonth:
+		start = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+		next = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+		bucket = monthBucket
+		layout = func(t time.Time) string { return t.Format("2006-01") }
+	default:
+		return nil, nil, fmt.Errorf("%w: unknown time grouping %q", ErrInvalidQuery, group)
+	}
+	for t := start; !t.After(to); t = next(t) {
+		if len(buckets) == maxStatBuckets {
+			return nil, nil, ErrTooManyBuckets
+		}
+		labels = append(labels, layout(t))
+		buckets = append(buckets, bucket(t))
+	}
+	return labels, buckets, nil
+}
+
+// coverBuckets return the smallest set of day and month buckets exactly covering [from, to] window
+func coverBuckets(from, to time.Time) ([]string, error) {
+	from, to = truncateDay(from), truncateDay(to)
+	buckets := []string{}
+	for t := from; !t.After(to); {
+		if len(buckets) == maxStatBuckets {
+			return nil, ErrTooManyBuckets
+		}
+		monthEnd := t.AddDate(0, 1, -1)
+		if t.Day() == 1 && !monthEnd.After(to) {
+			buckets = append(buckets, monthBucket(t))
+			t = t.AddDate(0, 1, 0)
+			continue
+		}
+		buckets = append(buckets, dayBucket(t))
+		t = t.AddDate(0, 0, 1)
+	}
+	return buckets, nil
+}
@@ -0,0 +1,162 @@
+package post
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/PostService/infrastructure/logger"
+	"github.com/PostService/internal/authz"
+	"github.com/PostService/model"
+)
+
+// purgeBatch is amount of posts removed from trash per cache call
+const purgeBatch = 100
+
+// DeletePost moves post to trash, so it is excluded from all listings until restored or purged
+// Post is deleted only if its current revision matches expected version
+func (s *service) DeletePost(id string, actor model.Viewer, version int64) error {
+	post, blob, err := s.getPost(id)
+	if err != nil {
+		return err
+	}
+	if post.DeletedAt != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err := authorize(actor, authz.ActionDelete, owns(post, actor)); err != nil {
+		return err
+	}
+	if err := s.checkVersion(post, version); err != nil {
+		return err
+	}
+	deletedAt := time.Now().UTC().Truncate(time.Second)
+	post.DeletedAt = &deletedAt
+	postBytes, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+
+	if err := s.cache.TrashPost(blob, newEntry(post, string(postBytes)), deletedAt.Unix()); err != nil {
+		return conflictErr(err)
+	}
+	return nil
+}
+
+// RestorePost takes post out of trash and returns it to all listings
+func (s *service) RestorePost(id string, actor model.Viewer) error {
+	post, blob, err := s.getPost(id)
+	if err != nil {
+		return err
+	}
+	if post.DeletedAt == nil {
+		return fmt.Errorf("%w in trash: %s", ErrNotFound, id)
+	}
+	if err := authorize(actor, authz.ActionDelete, owns(post, actor)); err != nil {
+		return err
+	}
+	post.DeletedAt = nil
+	postBytes, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+
+	restored, err := s.cache.RestorePost(blob, newEntry(post, string(postBytes)))
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return fmt.Errorf("%w in trash: %s", ErrNotFound, id)
+	}
+	return nil
+}
+
+// GetTrash return page of deleted posts viewer may restore, recently deleted first, and total amount of them
+func (s *service) GetTrash(offset, limit int64, viewer model.Viewer) ([]model.Post, int64, error) {
+	owner, err := trashOwner(viewer)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids, total, err := s.cache.GetTrash(owner, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	resList, err := s.cache.GetPosts(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	postList, err := decodePosts(resList)
+	if err != nil {
+		return nil, 0, err
+	}
+	return postList, total, nil
+}
+
+// trashOwner return identity of owner whose deleted posts viewer may see, empty for all owners
+// Anonymous viewer reaches trash only while authentication is disabled, so it sees all posts
+func trashOwner(viewer model.Viewer) (string, error) {
+	if viewer.Name == "" {
+		return "", nil
+	}
+	switch authz.Allowed(viewer, authz.ActionDelete) {
+	case authz.ScopeAll:
+		return "", nil
+	case authz.ScopeOwn:
+		if viewer.ID != "" {
+			return viewer.ID, nil
+		}
+	}
+	return "", authz.Authorize(viewer, authz.ActionDelete, false)
+}
+
+// PurgeTrash permanently removes posts deleted before given time and return amount of them
+func (s *service) PurgeTrash(before time.Time) (int64, error) {
+	var purged int64
+	for {
+		n, err := s.cache.PurgeTrash(before.Unix(), purgeBatch)
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+		if n < purgeBatch {
+			return purged, nil
+		}
+	}
+}
+
+// Purger periodically removes posts which stay in trash longer than retention period
+type Purger struct {
+	svc       Service
+	log       logger.Logger
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewPurger return Purger removing posts older than retention every interval
+func NewPurger(svc Service, log logger.Logger, retention, interval time.Duration) *Purger {
+	return &Purger{svc: svc, log: log, retention: retention, interval: interval}
+}
+
+// Run purges trash right away and then every interval until stop is closed
+func (p *Purger) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.purge()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) purge() {
+	purged, err := p.svc.PurgeTrash(time.Now().Add(-p.retention))
+	if err != nil {
+		p.log.Error(err.Error())
+		return
+	}
+	if purged > 0 {
+		p.log.Printf("%d posts purged from trash", purged)
+	}
+}
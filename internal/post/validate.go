@@ -0,0 +1,83 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/PostService/model"
+)
+
+// Size limits of post content, lengths are in characters
+const (
+	MaxSummaryLen       = 1000
+	MaxBodyLen          = 64 * 1024
+	MaxTags             = 20
+	MaxTagLen           = 50
+	MaxLanguageLen      = 35
+	MaxMetadataEntries  = 50
+	MaxMetadataKeyLen   = 64
+	MaxMetadataValueLen = 1024
+)
+
+// ErrInvalidPost returned when post content breaks limits
+var ErrInvalidPost = errors.New("invalid post")
+
+// reservedAuthor reports whether author key can not be routed as /post/{author},
+// because /post/search and /post/{id} take such paths
+func reservedAuthor(author string) bool {
+	key := NormalizeKey(author)
+	if key == "search" {
+		return true
+	}
+	for _, r := range key {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return key != ""
+}
+
+// validatePost checks post status, author and content against size limits
+func validatePost(post model.Post) error {
+	if reservedAuthor(post.Author) {
+		return fmt.Errorf("%w: author %q is reserved", ErrInvalidPost, post.Author)
+	}
+	switch post.Status {
+	case "", model.StatusDraft, model.StatusScheduled, model.StatusPublished:
+	default:
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidPost, post.Status)
+	}
+	if n := utf8.RuneCountInString(post.Summary); n > MaxSummaryLen {
+		return fmt.Errorf("%w: summary is %d characters long, limit is %d", ErrInvalidPost, n, MaxSummaryLen)
+	}
+	if n := utf8.RuneCountInString(post.Body); n > MaxBodyLen {
+		return fmt.Errorf("%w: body is %d characters long, limit is %d", ErrInvalidPost, n, MaxBodyLen)
+	}
+	if n := utf8.RuneCountInString(post.Language); n > MaxLanguageLen {
+		return fmt.Errorf("%w: language is %d characters long, limit is %d", ErrInvalidPost, n, MaxLanguageLen)
+	}
+	if len(post.Tags) > MaxTags {
+		return fmt.Errorf("%w: post has %d tags, limit is %d", ErrInvalidPost, len(post.Tags), MaxTags)
+	}
+	for _, tag := range post.Tags {
+		if tag == "" {
+			return fmt.Errorf("%w: empty tag", ErrInvalidPost)
+		}
+		if n := utf8.RuneCountInString(tag); n > MaxTagLen {
+			return fmt.Errorf("%w: tag %q is %d characters long, limit is %d", ErrInvalidPost, tag, n, MaxTagLen)
+		}
+	}
+	if len(post.Metadata) > MaxMetadataEntries {
+		return fmt.Errorf("%w: metadata has %d entries, limit is %d", ErrInvalidPost, len(post.Metadata), MaxMetadataEntries)
+	}
+	for k, v := range post.Metadata {
+		if n := utf8.RuneCountInString(k); k == "" || n > MaxMetadataKeyLen {
+			return fmt.Errorf("%w: metadata key %q must be 1 to %d characters long", ErrInvalidPost, k, MaxMetadataKeyLen)
+		}
+		if n := utf8.RuneCountInString(v); n > MaxMetadataValueLen {
+			return fmt.Errorf("%w: metadata value of %q is %d characters long, limit is %d", ErrInvalidPost, k, n, MaxMetadataValueLen)
+		}
+	}
+	return nil
+}
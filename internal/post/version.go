@@ -0,0 +1,88 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PostService/internal/post/cache"
+	"github.com/PostService/model"
+)
+
+// Expected versions which do not name particular post revision
+const (
+	// VersionUnknown means that client did not send expected version
+	VersionUnknown int64 = -1
+	// VersionAny means that any current version is accepted
+	VersionAny int64 = -2
+)
+
+// ErrNotFound returned when requested post does not exist
+var ErrNotFound = errors.New("post not found")
+
+// ErrVersionMismatch returned when post was changed since client has read it
+var ErrVersionMismatch = errors.New("post version does not match")
+
+// ErrVersionRequired returned when post is changed without expected version while it is required
+var ErrVersionRequired = errors.New("post version is required")
+
+// checkVersion checks that current post revision is the one client expects
+func (s *service) checkVersion(post model.Post, version int64) error {
+	switch version {
+	case VersionUnknown:
+		if s.requireVersion {
+			return ErrVersionRequired
+		}
+		return nil
+	case VersionAny:
+		return nil
+	}
+	if version != post.Revision {
+		return fmt.Errorf("%w: current revision is %d", ErrVersionMismatch, post.Revision)
+	}
+	return nil
+}
+
+// conflictErr reports concurrent change of post as version mismatch
+func conflictErr(err error) error {
+	if errors.Is(err, cache.ErrConflict) {
+		return fmt.Errorf("%w: post was changed concurrently", ErrVersionMismatch)
+	}
+	return err
+}
+
+// Version return version of posts matching query, it changes whenever any post
+// is added to, updated in or removed from indexes read by query
+func (s *service) Version(q Query) (model.Version, error) {
+	if err := validateQuery(q); err != nil {
+		return model.Version{}, err
+	}
+	iq, err := visibleQuery(q)
+	if err != nil {
+		return model.Version{}, err
+	}
+	v, err := s.cache.IndexVersion(iq)
+	if err != nil {
+		return model.Version{}, err
+	}
+	return newVersion(v), nil
+}
+
+// PostVersion return version of post record, posts stored before versions were
+// tracked have zero version until changed
+func (s *service) PostVersion(id string) (model.Version, error) {
+	v, err := s.cache.PostVersion(id)
+	if err != nil {
+		return model.Version{}, err
+	}
+	return newVersion(v), nil
+}
+
+// newVersion converts stored version
+func newVersion(v cache.Version) model.Version {
+	resp := model.Version{Counter: v.Counter}
+	if v.Modified > 0 {
+		resp.Modified = time.Unix(v.Modified, 0).UTC()
+	}
+	return resp
+}
@@ -0,0 +1,123 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PostService/internal/authz"
+	"github.com/PostService/internal/post/cache"
+	"github.com/PostService/model"
+)
+
+// Actions changing post status
+const (
+	actionPublish   = "publish"
+	actionUnpublish = "unpublish"
+)
+
+// transitions lists statuses post may have for every action, posts without status are published
+var transitions = map[string]map[string]bool{
+	actionPublish:   {model.StatusDraft: true},
+	actionUnpublish: {model.StatusScheduled: true, model.StatusPublished: true},
+}
+
+// ErrInvalidTransition returned when action is not allowed in current post status
+var ErrInvalidTransition = errors.New("invalid post status transition")
+
+// ErrForbidden returned when viewer may not see or change requested posts
+var ErrForbidden = authz.ErrForbidden
+
+// PublishPost publishes draft, post dated in the future becomes scheduled
+// Post is changed only if its current revision matches expected version
+func (s *service) PublishPost(id string, actor model.Viewer, version int64) (model.Post, error) {
+	return s.transit(id, actionPublish, actor, version, time.Now())
+}
+
+// UnpublishPost turns published or scheduled post back into draft
+// Post is changed only if its current revision matches expected version
+func (s *service) UnpublishPost(id string, actor model.Viewer, version int64) (model.Post, error) {
+	return s.transit(id, actionUnpublish, actor, version, time.Now())
+}
+
+// transit applies action to post status as a new revision made by actor
+func (s *service) transit(id, action string, actor model.Viewer, version int64, now time.Time) (model.Post, error) {
+	post, _, err := s.getPost(id)
+	if err != nil {
+		return model.Post{}, err
+	}
+	if post.DeletedAt != nil {
+		return model.Post{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err := authorize(actor, authz.ActionPublish, owns(post, actor)); err != nil {
+		return model.Post{}, err
+	}
+	status := postStatus(post)
+	if !transitions[action][status] {
+		return model.Post{}, fmt.Errorf("%w: can not %s %s post", ErrInvalidTransition, action, status)
+	}
+	if err := s.checkVersion(post, version); err != nil {
+		return model.Post{}, err
+	}
+
+	post.Status = model.StatusDraft
+	if action == actionPublish {
+		post.Status = model.StatusPublished
+	}
+	return s.update(id, post, actor, post.Revision, now)
+}
+
+// authorize checks that actor may act on post, own tells whether actor owns it. Changes
+// of anonymous actor reach service only while authentication is disabled, so they are not checked
+func authorize(actor model.Viewer, action authz.Action, own bool) error {
+	if actor.Name == "" {
+		return nil
+	}
+	return authz.Authorize(actor, action, own)
+}
+
+// editorName return name of actor recorded in revisions, changes of anonymous actor
+// are made on behalf of post author
+func editorName(actor model.Viewer, post model.Post) string {
+	if actor.Name != "" {
+		return actor.Name
+	}
+	return strings.TrimSpace(post.Author)
+}
+
+// visible reports whether viewer may see post
+func visible(post model.Post, viewer model.Viewer) bool {
+	return isPublished(post) || authz.Authorize(viewer, authz.ActionView, owns(post, viewer)) == nil
+}
+
+// owns reports whether viewer created post, posts are owned by identity of principal,
+// so author names, which are not unique, do not grant any rights
+func owns(post model.Post, viewer model.Viewer) bool {
+	return viewer.ID != "" && post.AuthorID == viewer.ID
+}
+
+// signs reports whether author is the name of viewer, viewer may write posts under its own name
+func signs(author string, viewer model.Viewer) bool {
+	return viewer.Name != "" && NormalizeKey(author) == NormalizeKey(viewer.Name)
+}
+
+// visibleQuery translates query into lookup of posts viewer may see: editors and admins
+// see all unpublished posts on request and nobody else may request them, except that
+// posts listed under name of viewer include its own unpublished posts
+func visibleQuery(q Query) (cache.IndexQuery, error) {
+	own := q.Author != "" && q.Viewer.ID != "" && signs(q.Author, q.Viewer)
+	if q.Unpublished {
+		if err := authz.Authorize(q.Viewer, authz.ActionView, false); err != nil {
+			if !own {
+				return cache.IndexQuery{}, err
+			}
+			q.Unpublished = false
+		}
+	}
+	iq := indexQuery(q)
+	if own && !iq.Unpublished {
+		iq.Owner = q.Viewer.ID
+	}
+	return iq, nil
+}
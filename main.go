@@ -1,23 +1,32 @@
 package main
 
 import (
+	"flag"
 	baseLog "log"
 	"net/http"
+	"time"
 
+	"github.com/PostService/infrastructure/breaker"
+	"github.com/PostService/infrastructure/certs"
 	"github.com/PostService/infrastructure/config"
 	"github.com/PostService/infrastructure/datastore"
 	"github.com/PostService/infrastructure/logger"
+	"github.com/PostService/internal/post"
+	postCache "github.com/PostService/internal/post/cache"
+	"github.com/PostService/web/ratelimit"
 	"github.com/PostService/web/router"
 	"github.com/go-redis/redis"
-	"github.com/gorilla/handlers"
 )
 
 func main() {
+	migrate := flag.Bool("migrate", false, "import posts stored in author and post name lists by older versions, rebuild post indexes and exit")
+	flag.Parse()
+
 	configFilePath := "config.json"
 	var (
 		conf        config.Configuration
 		log         logger.Logger
-		redisClient *redis.Client
+		redisClient redis.UniversalClient
 		err         error
 	)
 
@@ -31,14 +40,60 @@ func main() {
 		baseLog.Fatal(err.Error())
 	}
 
-	if redisClient, err = datastore.NewRedis(redis.Options{
-		Addr:     conf.Redis.Address,
-		Password: conf.Redis.Password,
-		DB:       conf.Redis.DB,
-	}); err != nil {
+	if redisClient, err = datastore.NewRedis(conf.Redis, log); err != nil {
 		log.Fatal(err.Error())
 	}
 
+	if *migrate {
+		imported, err := post.Migrate(postCache.NewPostCache(redisClient))
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		log.Printf("Migration finished, %d posts imported", imported)
+		return
+	}
+
+	// posts storage and rate limits are shared by requests and background jobs, so they all
+	// fail fast while redis is unhealthy
+	var redisBreaker *breaker.Breaker
+	pc := postCache.NewPostCache(redisClient)
+	if brk := conf.Redis.CircuitBreaker; brk.FailureThreshold > 0 {
+		openFor := time.Duration(brk.OpenSeconds) * time.Second
+		if openFor <= 0 {
+			openFor = 10 * time.Second
+		}
+		redisBreaker = breaker.New(brk.FailureThreshold, openFor, brk.HalfOpenProbes,
+			func(from, to breaker.State) {
+				log.Printf("redis circuit breaker changed from %s to %s", from, to)
+			})
+		pc = postCache.NewBreakerCache(pc, redisBreaker)
+	}
+
+	// requests and background jobs share posts service, so changes of all of them drop cached reads
+	postSvc := post.NewPostService(pc, conf.Posts)
+	if conf.ReadCache.Enabled {
+		readCache := post.NewReadCache(postSvc, pc, log, conf.ReadCache)
+		go readCache.Run(make(chan struct{}))
+		postSvc = readCache
+	}
+
+	if conf.Trash.RetentionHours > 0 {
+		interval := time.Duration(conf.Trash.PurgeIntervalMinutes) * time.Minute
+		if interval <= 0 {
+			interval = time.Hour
+		}
+		purger := post.NewPurger(postSvc, log,
+			time.Duration(conf.Trash.RetentionHours)*time.Hour, interval)
+		go purger.Run(make(chan struct{}))
+	}
+
+	publishInterval := time.Duration(conf.Posts.PublishIntervalSeconds) * time.Second
+	if publishInterval <= 0 {
+		publishInterval = time.Minute
+	}
+	scheduler := post.NewScheduler(postSvc, log, publishInterval)
+	go scheduler.Run(make(chan struct{}))
+
 	requestInfo := func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			message := " | " + r.Method + " | " + r.URL.RequestURI()
@@ -47,9 +102,34 @@ func main() {
 		})
 	}
 
-	mainRouter, headers, methods, origins, err := router.New(log, redisClient)
+	mainRouter, err := router.New(log, postSvc, conf)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
-	log.Fatal(http.ListenAndServe(conf.ListenPort, requestInfo(handlers.CORS(headers, methods, origins)(mainRouter))))
+	cors, err := router.CORS(conf.CORS, conf.Environment)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	// route limits are checked after routing and authentication to tell routes and clients apart,
+	// authentication attempts are limited per IP before authentication, so rejected credentials count too
+	limiter := ratelimit.NewRedis(redisClient, redisBreaker, postCache.Unavailable, log)
+	limit, err := ratelimit.Middleware(log, limiter, conf.RateLimit)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	mainRouter.Use(limit)
+	limitAuthentication, err := ratelimit.Authentication(log, limiter, conf.RateLimit)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	handler := limitAuthentication(mainRouter)
+	server := &http.Server{Addr: conf.ListenPort, Handler: requestInfo(cors(handler))}
+	if conf.TLS.CertFile != "" {
+		if server.TLSConfig, err = certs.ServerConfig(conf.TLS, log); err != nil {
+			log.Fatal(err.Error())
+		}
+		// certificate is served by TLS configuration, so it is reloaded without restart
+		log.Fatal(server.ListenAndServeTLS("", ""))
+	}
+	log.Fatal(server.ListenAndServe())
 }
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	cache "github.com/PostService/internal/post/cache"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
 )
@@ -32,31 +33,408 @@ func (m *MockPostCache) EXPECT() *MockPostCacheMockRecorder {
 	return m.recorder
 }
 
-// GetPostsByKey mocks base method
-func (m *MockPostCache) GetPostsByKey(key string) ([]string, error) {
+// GetPosts mocks base method
+func (m *MockPostCache) GetPosts(ids []string) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostsByKey", key)
+	ret := m.ctrl.Call(m, "GetPosts", ids)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetPostsByKey indicates an expected call of GetPostsByKey
-func (mr *MockPostCacheMockRecorder) GetPostsByKey(key interface{}) *gomock.Call {
+// GetPosts indicates an expected call of GetPosts
+func (mr *MockPostCacheMockRecorder) GetPosts(ids interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByKey", reflect.TypeOf((*MockPostCache)(nil).GetPostsByKey), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPosts", reflect.TypeOf((*MockPostCache)(nil).GetPosts), ids)
 }
 
-// InsertPost mocks base method
-func (m *MockPostCache) InsertPost(key, post string) error {
+// NextID mocks base method
+func (m *MockPostCache) NextID() (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertPost", key, post)
+	ret := m.ctrl.Call(m, "NextID")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NextID indicates an expected call of NextID
+func (mr *MockPostCacheMockRecorder) NextID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextID", reflect.TypeOf((*MockPostCache)(nil).NextID))
+}
+
+// SavePost mocks base method
+func (m *MockPostCache) SavePost(e cache.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePost", e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePost indicates an expected call of SavePost
+func (mr *MockPostCacheMockRecorder) SavePost(e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePost", reflect.TypeOf((*MockPostCache)(nil).SavePost), e)
+}
+
+// CreatePost mocks base method
+func (m *MockPostCache) CreatePost(e cache.Entry, h cache.History) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePost", e, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePost indicates an expected call of CreatePost
+func (mr *MockPostCacheMockRecorder) CreatePost(e, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockPostCache)(nil).CreatePost), e, h)
+}
+
+// Search mocks base method
+func (m *MockPostCache) Search(terms []string, offset, count int64) ([]string, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", terms, offset, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Search indicates an expected call of Search
+func (mr *MockPostCacheMockRecorder) Search(terms, offset, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockPostCache)(nil).Search), terms, offset, count)
+}
+
+// GetAuthors mocks base method
+func (m *MockPostCache) GetAuthors(prefix string, offset, count int64) ([]cache.Author, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthors", prefix, offset, count)
+	ret0, _ := ret[0].([]cache.Author)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAuthors indicates an expected call of GetAuthors
+func (mr *MockPostCacheMockRecorder) GetAuthors(prefix, offset, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthors", reflect.TypeOf((*MockPostCache)(nil).GetAuthors), prefix, offset, count)
+}
+
+// CountPosts mocks base method
+func (m *MockPostCache) CountPosts(buckets []string) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPosts", buckets)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPosts indicates an expected call of CountPosts
+func (mr *MockPostCacheMockRecorder) CountPosts(buckets interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPosts", reflect.TypeOf((*MockPostCache)(nil).CountPosts), buckets)
+}
+
+// TopAuthors mocks base method
+func (m *MockPostCache) TopAuthors(buckets []string, count int64) ([]cache.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopAuthors", buckets, count)
+	ret0, _ := ret[0].([]cache.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopAuthors indicates an expected call of TopAuthors
+func (mr *MockPostCacheMockRecorder) TopAuthors(buckets, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopAuthors", reflect.TypeOf((*MockPostCache)(nil).TopAuthors), buckets, count)
+}
+
+// GetTags mocks base method
+func (m *MockPostCache) GetTags(offset, count int64) ([]cache.Tag, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTags", offset, count)
+	ret0, _ := ret[0].([]cache.Tag)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTags indicates an expected call of GetTags
+func (mr *MockPostCacheMockRecorder) GetTags(offset, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTags", reflect.TypeOf((*MockPostCache)(nil).GetTags), offset, count)
+}
+
+// DeleteKeys mocks base method
+func (m *MockPostCache) DeleteKeys(keys ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteKeys", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteKeys indicates an expected call of DeleteKeys
+func (mr *MockPostCacheMockRecorder) DeleteKeys(keys ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteKeys", reflect.TypeOf((*MockPostCache)(nil).DeleteKeys), keys...)
+}
+
+// FindPosts mocks base method
+func (m *MockPostCache) FindPosts(q cache.IndexQuery) ([]string, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPosts", q)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindPosts indicates an expected call of FindPosts
+func (mr *MockPostCacheMockRecorder) FindPosts(q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPosts", reflect.TypeOf((*MockPostCache)(nil).FindPosts), q)
+}
+
+// GetList mocks base method
+func (m *MockPostCache) GetList(key string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetList", key)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetList indicates an expected call of GetList
+func (mr *MockPostCacheMockRecorder) GetList(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetList", reflect.TypeOf((*MockPostCache)(nil).GetList), key)
+}
+
+// ScanKeys mocks base method
+func (m *MockPostCache) ScanKeys(match, typ string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanKeys", match, typ)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScanKeys indicates an expected call of ScanKeys
+func (mr *MockPostCacheMockRecorder) ScanKeys(match, typ interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanKeys", reflect.TypeOf((*MockPostCache)(nil).ScanKeys), match, typ)
+}
+
+// PostIDs mocks base method
+func (m *MockPostCache) PostIDs() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostIDs")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostIDs indicates an expected call of PostIDs
+func (mr *MockPostCacheMockRecorder) PostIDs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostIDs", reflect.TypeOf((*MockPostCache)(nil).PostIDs))
+}
+
+// GetTrash mocks base method
+func (m *MockPostCache) GetTrash(owner string, offset, count int64) ([]string, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrash", owner, offset, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTrash indicates an expected call of GetTrash
+func (mr *MockPostCacheMockRecorder) GetTrash(owner, offset, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrash", reflect.TypeOf((*MockPostCache)(nil).GetTrash), owner, offset, count)
+}
+
+// PurgeTrash mocks base method
+func (m *MockPostCache) PurgeTrash(before, count int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", before, count)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash
+func (mr *MockPostCacheMockRecorder) PurgeTrash(before, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockPostCache)(nil).PurgeTrash), before, count)
+}
+
+// RestorePost mocks base method
+func (m *MockPostCache) RestorePost(old string, e cache.Entry) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestorePost", old, e)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestorePost indicates an expected call of RestorePost
+func (mr *MockPostCacheMockRecorder) RestorePost(old, e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePost", reflect.TypeOf((*MockPostCache)(nil).RestorePost), old, e)
+}
+
+// TrashPost mocks base method
+func (m *MockPostCache) TrashPost(old string, e cache.Entry, deletedAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrashPost", old, e, deletedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TrashPost indicates an expected call of TrashPost
+func (mr *MockPostCacheMockRecorder) TrashPost(old, e, deletedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrashPost", reflect.TypeOf((*MockPostCache)(nil).TrashPost), old, e, deletedAt)
+}
+
+// IndexTrash mocks base method
+func (m *MockPostCache) IndexTrash(e cache.Entry, deletedAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IndexTrash", e, deletedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IndexTrash indicates an expected call of IndexTrash
+func (mr *MockPostCacheMockRecorder) IndexTrash(e, deletedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexTrash", reflect.TypeOf((*MockPostCache)(nil).IndexTrash), e, deletedAt)
+}
+
+// GetRevisions mocks base method
+func (m *MockPostCache) GetRevisions(id string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevisions", id)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevisions indicates an expected call of GetRevisions
+func (mr *MockPostCacheMockRecorder) GetRevisions(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisions", reflect.TypeOf((*MockPostCache)(nil).GetRevisions), id)
+}
+
+// UpdatePost mocks base method
+func (m *MockPostCache) UpdatePost(old, e cache.Entry, h cache.History) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePost", old, e, h)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// InsertPost indicates an expected call of InsertPost
-func (mr *MockPostCacheMockRecorder) InsertPost(key, post interface{}) *gomock.Call {
+// UpdatePost indicates an expected call of UpdatePost
+func (mr *MockPostCacheMockRecorder) UpdatePost(old, e, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePost", reflect.TypeOf((*MockPostCache)(nil).UpdatePost), old, e, h)
+}
+
+// IndexVersion mocks base method
+func (m *MockPostCache) IndexVersion(q cache.IndexQuery) (cache.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IndexVersion", q)
+	ret0, _ := ret[0].(cache.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IndexVersion indicates an expected call of IndexVersion
+func (mr *MockPostCacheMockRecorder) IndexVersion(q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexVersion", reflect.TypeOf((*MockPostCache)(nil).IndexVersion), q)
+}
+
+// PostVersion mocks base method
+func (m *MockPostCache) PostVersion(id string) (cache.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostVersion", id)
+	ret0, _ := ret[0].(cache.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostVersion indicates an expected call of PostVersion
+func (mr *MockPostCacheMockRecorder) PostVersion(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostVersion", reflect.TypeOf((*MockPostCache)(nil).PostVersion), id)
+}
+
+// DuePosts mocks base method
+func (m *MockPostCache) DuePosts(before, count int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DuePosts", before, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DuePosts indicates an expected call of DuePosts
+func (mr *MockPostCacheMockRecorder) DuePosts(before, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DuePosts", reflect.TypeOf((*MockPostCache)(nil).DuePosts), before, count)
+}
+
+// Notify mocks base method
+func (m *MockPostCache) Notify(event string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Notify", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Notify indicates an expected call of Notify
+func (mr *MockPostCacheMockRecorder) Notify(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*MockPostCache)(nil).Notify), event)
+}
+
+// Invalidate mocks base method
+func (m *MockPostCache) Invalidate(invalidation string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Invalidate", invalidation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Invalidate indicates an expected call of Invalidate
+func (mr *MockPostCacheMockRecorder) Invalidate(invalidation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidate", reflect.TypeOf((*MockPostCache)(nil).Invalidate), invalidation)
+}
+
+// Invalidations mocks base method
+func (m *MockPostCache) Invalidations(stop <-chan struct{}) (<-chan string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Invalidations", stop)
+	ret0, _ := ret[0].(<-chan string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Invalidations indicates an expected call of Invalidations
+func (mr *MockPostCacheMockRecorder) Invalidations(stop interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPost", reflect.TypeOf((*MockPostCache)(nil).InsertPost), key, post)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidations", reflect.TypeOf((*MockPostCache)(nil).Invalidations), stop)
 }
@@ -8,6 +8,7 @@ import (
 	model "github.com/PostService/model"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockService is a mock of Service interface
@@ -34,45 +35,303 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 }
 
 // InsertPost mocks base method
-func (m *MockService) InsertPost(post model.Post) error {
+func (m *MockService) InsertPost(post model.Post, actor model.Viewer) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertPost", post)
+	ret := m.ctrl.Call(m, "InsertPost", post, actor)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // InsertPost indicates an expected call of InsertPost
-func (mr *MockServiceMockRecorder) InsertPost(post interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) InsertPost(post, actor interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPost", reflect.TypeOf((*MockService)(nil).InsertPost), post)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPost", reflect.TypeOf((*MockService)(nil).InsertPost), post, actor)
 }
 
-// GetPostsByKey mocks base method
-func (m *MockService) GetPostsByKey(key string) ([]model.Post, error) {
+// SearchPosts mocks base method
+func (m *MockService) SearchPosts(query string, offset, limit int64) ([]model.Post, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostsByKey", key)
+	ret := m.ctrl.Call(m, "SearchPosts", query, offset, limit)
 	ret0, _ := ret[0].([]model.Post)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchPosts indicates an expected call of SearchPosts
+func (mr *MockServiceMockRecorder) SearchPosts(query, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPosts", reflect.TypeOf((*MockService)(nil).SearchPosts), query, offset, limit)
+}
+
+// GetAuthors mocks base method
+func (m *MockService) GetAuthors(prefix string, offset, limit int64) ([]model.Author, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthors", prefix, offset, limit)
+	ret0, _ := ret[0].([]model.Author)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAuthors indicates an expected call of GetAuthors
+func (mr *MockServiceMockRecorder) GetAuthors(prefix, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthors", reflect.TypeOf((*MockService)(nil).GetAuthors), prefix, offset, limit)
+}
+
+// GetStats mocks base method
+func (m *MockService) GetStats(q model.StatsQuery) ([]model.Stat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", q)
+	ret0, _ := ret[0].([]model.Stat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetPostsByKey indicates an expected call of GetPostsByKey
-func (mr *MockServiceMockRecorder) GetPostsByKey(key interface{}) *gomock.Call {
+// GetStats indicates an expected call of GetStats
+func (mr *MockServiceMockRecorder) GetStats(q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockService)(nil).GetStats), q)
+}
+
+// GetTags mocks base method
+func (m *MockService) GetTags(offset, limit int64) ([]model.Tag, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTags", offset, limit)
+	ret0, _ := ret[0].([]model.Tag)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTags indicates an expected call of GetTags
+func (mr *MockServiceMockRecorder) GetTags(offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTags", reflect.TypeOf((*MockService)(nil).GetTags), offset, limit)
+}
+
+// Find mocks base method
+func (m *MockService) Find(q model.Query) ([]model.Post, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", q)
+	ret0, _ := ret[0].([]model.Post)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Find indicates an expected call of Find
+func (mr *MockServiceMockRecorder) Find(q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockService)(nil).Find), q)
+}
+
+// DeletePost mocks base method
+func (m *MockService) DeletePost(id string, actor model.Viewer, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePost", id, actor, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePost indicates an expected call of DeletePost
+func (mr *MockServiceMockRecorder) DeletePost(id, actor, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByKey", reflect.TypeOf((*MockService)(nil).GetPostsByKey), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePost", reflect.TypeOf((*MockService)(nil).DeletePost), id, actor, version)
 }
 
-// GetPostsByNameAndAuthor mocks base method
-func (m *MockService) GetPostsByNameAndAuthor(name, author string) ([]model.Post, error) {
+// GetTrash mocks base method
+func (m *MockService) GetTrash(offset, limit int64, viewer model.Viewer) ([]model.Post, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostsByNameAndAuthor", name, author)
+	ret := m.ctrl.Call(m, "GetTrash", offset, limit, viewer)
 	ret0, _ := ret[0].([]model.Post)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTrash indicates an expected call of GetTrash
+func (mr *MockServiceMockRecorder) GetTrash(offset, limit, viewer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrash", reflect.TypeOf((*MockService)(nil).GetTrash), offset, limit, viewer)
+}
+
+// PurgeTrash mocks base method
+func (m *MockService) PurgeTrash(before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash
+func (mr *MockServiceMockRecorder) PurgeTrash(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockService)(nil).PurgeTrash), before)
+}
+
+// RestorePost mocks base method
+func (m *MockService) RestorePost(id string, actor model.Viewer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestorePost", id, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestorePost indicates an expected call of RestorePost
+func (mr *MockServiceMockRecorder) RestorePost(id, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePost", reflect.TypeOf((*MockService)(nil).RestorePost), id, actor)
+}
+
+// DiffRevisions mocks base method
+func (m *MockService) DiffRevisions(id string, from, to int64, viewer model.Viewer) ([]model.FieldChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiffRevisions", id, from, to, viewer)
+	ret0, _ := ret[0].([]model.FieldChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffRevisions indicates an expected call of DiffRevisions
+func (mr *MockServiceMockRecorder) DiffRevisions(id, from, to, viewer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffRevisions", reflect.TypeOf((*MockService)(nil).DiffRevisions), id, from, to, viewer)
+}
+
+// GetRevision mocks base method
+func (m *MockService) GetRevision(id string, number int64, viewer model.Viewer) (model.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", id, number, viewer)
+	ret0, _ := ret[0].(model.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision
+func (mr *MockServiceMockRecorder) GetRevision(id, number, viewer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockService)(nil).GetRevision), id, number, viewer)
+}
+
+// GetRevisions mocks base method
+func (m *MockService) GetRevisions(id string, viewer model.Viewer) ([]model.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevisions", id, viewer)
+	ret0, _ := ret[0].([]model.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevisions indicates an expected call of GetRevisions
+func (mr *MockServiceMockRecorder) GetRevisions(id, viewer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisions", reflect.TypeOf((*MockService)(nil).GetRevisions), id, viewer)
+}
+
+// UpdatePost mocks base method
+func (m *MockService) UpdatePost(id string, post model.Post, actor model.Viewer, version int64) (model.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePost", id, post, actor, version)
+	ret0, _ := ret[0].(model.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePost indicates an expected call of UpdatePost
+func (mr *MockServiceMockRecorder) UpdatePost(id, post, actor, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePost", reflect.TypeOf((*MockService)(nil).UpdatePost), id, post, actor, version)
+}
+
+// GetPost mocks base method
+func (m *MockService) GetPost(id string, viewer model.Viewer) (model.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPost", id, viewer)
+	ret0, _ := ret[0].(model.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPost indicates an expected call of GetPost
+func (mr *MockServiceMockRecorder) GetPost(id, viewer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPost", reflect.TypeOf((*MockService)(nil).GetPost), id, viewer)
+}
+
+// PostVersion mocks base method
+func (m *MockService) PostVersion(id string) (model.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostVersion", id)
+	ret0, _ := ret[0].(model.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostVersion indicates an expected call of PostVersion
+func (mr *MockServiceMockRecorder) PostVersion(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostVersion", reflect.TypeOf((*MockService)(nil).PostVersion), id)
+}
+
+// Version mocks base method
+func (m *MockService) Version(q model.Query) (model.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version", q)
+	ret0, _ := ret[0].(model.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Version indicates an expected call of Version
+func (mr *MockServiceMockRecorder) Version(q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockService)(nil).Version), q)
+}
+
+// PublishScheduled mocks base method
+func (m *MockService) PublishScheduled(now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishScheduled", now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishScheduled indicates an expected call of PublishScheduled
+func (mr *MockServiceMockRecorder) PublishScheduled(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishScheduled", reflect.TypeOf((*MockService)(nil).PublishScheduled), now)
+}
+
+// PublishPost mocks base method
+func (m *MockService) PublishPost(id string, actor model.Viewer, version int64) (model.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishPost", id, actor, version)
+	ret0, _ := ret[0].(model.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishPost indicates an expected call of PublishPost
+func (mr *MockServiceMockRecorder) PublishPost(id, actor, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishPost", reflect.TypeOf((*MockService)(nil).PublishPost), id, actor, version)
+}
+
+// UnpublishPost mocks base method
+func (m *MockService) UnpublishPost(id string, actor model.Viewer, version int64) (model.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpublishPost", id, actor, version)
+	ret0, _ := ret[0].(model.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetPostsByNameAndAuthor indicates an expected call of GetPostsByNameAndAuthor
-func (mr *MockServiceMockRecorder) GetPostsByNameAndAuthor(name, author interface{}) *gomock.Call {
+// UnpublishPost indicates an expected call of UnpublishPost
+func (mr *MockServiceMockRecorder) UnpublishPost(id, actor, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByNameAndAuthor", reflect.TypeOf((*MockService)(nil).GetPostsByNameAndAuthor), name, author)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpublishPost", reflect.TypeOf((*MockService)(nil).UnpublishPost), id, actor, version)
 }
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Author entity with statistic of author's posts
+type Author struct {
+	Name       string    `json:"author"`
+	Posts      int64     `json:"posts"`
+	LatestPost time.Time `json:"latest_post"`
+}
+
+// MarshalJSON needed for formatting latest post date parameter
+func (a *Author) MarshalJSON() ([]byte, error) {
+	type Alias Author
+	return json.Marshal(&struct {
+		*Alias
+		LatestPost string `json:"latest_post"`
+	}{
+		Alias:      (*Alias)(a),
+		LatestPost: a.LatestPost.Format(DateFormat),
+	})
+}
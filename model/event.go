@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Types of post events
+const (
+	EventPublished = "published"
+)
+
+// PostEvent is notification about change of post state sent to subscribers
+type PostEvent struct {
+	Type string    `json:"type"`
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+}
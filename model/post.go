@@ -5,11 +5,37 @@ import (
 	"time"
 )
 
+// DateFormat is layout used for post's date in requests and responses
+const DateFormat = "02.01.06"
+
+// Post statuses, post without status is published
+const (
+	// StatusDraft post is stored but never listed until it gets other status
+	StatusDraft = "draft"
+	// StatusScheduled post is listed since its date
+	StatusScheduled = "scheduled"
+	// StatusPublished post is listed
+	StatusPublished = "published"
+)
+
 // Post entity
 type Post struct {
-	Name   string    `json:"post_name"`
-	Date   time.Time `json:"date"`
-	Author string    `json:"author"`
+	ID       string            `json:"id,omitempty"`
+	Name     string            `json:"post_name"`
+	Date     time.Time         `json:"date"`
+	Author   string            `json:"author"`
+	Summary  string            `json:"summary,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Language string            `json:"language,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Status   string            `json:"status,omitempty"`
+	// AuthorID is identity of principal which created post, it owns post and is never set by clients
+	AuthorID string `json:"author_id,omitempty"`
+	// Revision is number of the current post revision
+	Revision int64 `json:"revision,omitempty"`
+	// DeletedAt is set while post is in trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 // MarshalJSON needed for formatting date parameter
@@ -20,7 +46,7 @@ func (p *Post) MarshalJSON() ([]byte, error) {
 		Date string `json:"date"`
 	}{
 		Alias: (*Alias)(p),
-		Date:  time.Time(p.Date).Format("02.01.06"),
+		Date:  time.Time(p.Date).Format(DateFormat),
 	})
 }
 
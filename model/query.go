@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Orders of posts in query results
+const (
+	SortDateDesc = "date_desc"
+	SortDateAsc  = "date_asc"
+)
+
+// Query describes filters, order and page of requested posts
+// Zero filters are not applied, so query without filters matches all posts
+// Date range is inclusive, Tags are matched all unless AnyTag is set,
+// empty Sort means SortDateDesc, posts which are not published yet are matched
+// only if Unpublished is set and Viewer may see them
+type Query struct {
+	Name   string
+	Author string
+	From   time.Time
+	To     time.Time
+	Tags   []string
+	AnyTag bool
+	Sort   string
+	Offset int64
+	Limit  int64
+
+	Unpublished bool
+	Viewer      Viewer
+}
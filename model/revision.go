@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Revision is immutable version of post saved on its creation and every update
+// Changes lists fields changed since the previous revision
+type Revision struct {
+	Number  int64     `json:"revision"`
+	Editor  string    `json:"editor"`
+	Created time.Time `json:"created_at"`
+	Changes []string  `json:"changes,omitempty"`
+	Post    Post      `json:"post"`
+}
+
+// FieldChange is difference of post field between two revisions
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Stats grouping variants
+const (
+	GroupDay    = "day"
+	GroupWeek   = "week"
+	GroupMonth  = "month"
+	GroupAuthor = "author"
+)
+
+// Stat is amount of posts in a group (time bucket or author)
+type Stat struct {
+	Key   string `json:"key"`
+	Posts int64  `json:"posts"`
+}
+
+// StatsQuery describes requested posts statistic
+// For author grouping zero From and To mean the whole service lifetime
+type StatsQuery struct {
+	Group string
+	From  time.Time
+	To    time.Time
+	Top   int64
+}
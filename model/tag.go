@@ -0,0 +1,7 @@
+package model
+
+// Tag with amount of posts marked by it
+type Tag struct {
+	Name  string `json:"tag"`
+	Posts int64  `json:"posts"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Version is state of posts list or post used for conditional requests,
+// Counter grows on every change and Modified is time of the latest change,
+// zero Modified means that state is unknown since it never changed
+type Version struct {
+	Counter  int64
+	Modified time.Time
+}
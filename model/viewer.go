@@ -0,0 +1,11 @@
+package model
+
+// Viewer is client reading or changing posts, zero Viewer is anonymous and sees published posts only
+type Viewer struct {
+	// ID is stable identity of client, client sees and manages posts it created
+	ID string
+	// Name is author name of client, posts created by client without author are written by it
+	Name string
+	// Roles granted to client, they tell what client may do with posts of other authors
+	Roles []string
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/PostService/infrastructure/config"
+)
+
+// apiKeyHeader is request header carrying API key
+const apiKeyHeader = "X-API-Key"
+
+// apiKeyAuthenticator authenticates clients by static API keys
+type apiKeyAuthenticator struct {
+	keys []config.APIKeyConfig
+}
+
+func newAPIKeyAuthenticator(keys []config.APIKeyConfig) *apiKeyAuthenticator {
+	return &apiKeyAuthenticator{keys: keys}
+}
+
+// Authenticate return principal of API key from X-API-Key header
+func (a *apiKeyAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	key := r.Header.Get(apiKeyHeader)
+	if key == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	for _, k := range a.keys {
+		// constant time comparison does not reveal how much of a key matched
+		if k.Key != "" && subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+			return Principal{Subject: "apikey:" + k.Name, Name: k.Name, Roles: k.Roles}, nil
+		}
+	}
+	return Principal{}, ErrInvalidCredentials
+}
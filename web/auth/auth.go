@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/infrastructure/logger"
+)
+
+// ErrNoCredentials returned by Authenticator when request has no credentials it verifies
+var ErrNoCredentials = errors.New("no credentials")
+
+// ErrInvalidCredentials returned when request credentials are rejected
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal is authenticated client of request, Subject is its stable identity which owns
+// posts it creates and Name is author name of its posts
+type Principal struct {
+	Subject string
+	Name    string
+	Roles   []string
+}
+
+// HasRole reports whether principal is granted role
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator verifies one kind of request credentials
+type Authenticator interface {
+	// Authenticate return principal of request, ErrNoCredentials if request has no credentials of this kind
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// New return authenticators configured by conf, none means that authentication is disabled
+func New(conf config.AuthConfig) ([]Authenticator, error) {
+	authenticators := []Authenticator{}
+	if conf.JWT.Secret != "" || conf.JWT.JWKSFile != "" || conf.JWT.JWKSURL != "" {
+		a, err := newJWTAuthenticator(conf.JWT)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	for _, k := range conf.APIKeys {
+		// principal name is author name of client, posts can not be attributed to nameless one
+		if k.Name == "" {
+			return nil, errors.New("API key without name is configured")
+		}
+	}
+	if len(conf.APIKeys) > 0 {
+		authenticators = append(authenticators, newAPIKeyAuthenticator(conf.APIKeys))
+	}
+	return authenticators, nil
+}
+
+type contextKey struct{}
+
+// FromContext return principal attached to request context by Middleware
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
+
+// NewContext return copy of ctx carrying principal
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// HasCredentials reports whether request carries credentials of any supported kind
+func HasCredentials(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || r.Header.Get(apiKeyHeader) != ""
+}
+
+// Middleware attaches principal of request credentials to request context,
+// requests without credentials stay anonymous and requests with rejected ones get 401
+// Credentials are checked by the first authenticator which finds them
+func Middleware(log logger.Logger, authenticators []Authenticator) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, a := range authenticators {
+				p, err := a.Authenticate(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					log.Printf("authentication of %s %s failed: %v", r.Method, r.URL.Path, err)
+					unauthorized(w, err.Error())
+					return
+				}
+				r = r.WithContext(NewContext(r.Context(), p))
+				break
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Require answers anonymous requests by 401
+func Require(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := FromContext(r.Context()); !ok {
+			unauthorized(w, "authentication required")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="posts"`)
+	http.Error(w, message, http.StatusUnauthorized)
+}
@@ -0,0 +1,320 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "secret"
+
+func segment(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func hs256Token(t *testing.T, header, claims map[string]interface{}, secret string) string {
+	signed := segment(t, header) + "." + segment(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func rs256Token(t *testing.T, kid string, claims map[string]interface{}, key *rsa.PrivateKey) string {
+	signed := segment(t, map[string]interface{}{"alg": "RS256", "kid": kid}) + "." + segment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func bearer(token string) *http.Request {
+	r := httptest.NewRequest("GET", "/post", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestJWT(t *testing.T) {
+	a, err := newJWTAuthenticator(config.JWTConfig{Secret: testSecret, Issuer: "issuer", Audience: "posts", NameClaim: "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	exp := time.Now().Add(time.Hour).Unix()
+	claims := func(override map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{"sub": "42", "name": "alice", "roles": []string{"admin"},
+			"iss": "issuer", "aud": []string{"posts"}, "exp": exp}
+		for k, v := range override {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+
+	var testCases = []struct {
+		name      string
+		token     string
+		principal Principal
+		err       string
+	}{
+		{
+			name:      "valid",
+			token:     hs256Token(t, hs256, claims(nil), testSecret),
+			principal: Principal{Subject: "42", Name: "alice", Roles: []string{"admin"}},
+		},
+		{
+			name:      "name falls back to subject",
+			token:     hs256Token(t, hs256, claims(map[string]interface{}{"name": nil, "roles": "writer editor", "aud": "posts"}), testSecret),
+			principal: Principal{Subject: "42", Name: "42", Roles: []string{"writer", "editor"}},
+		},
+		{
+			name:  "expired",
+			token: hs256Token(t, hs256, claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}), testSecret),
+			err:   "invalid credentials: token is expired",
+		},
+		{
+			name:  "not valid yet",
+			token: hs256Token(t, hs256, claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()}), testSecret),
+			err:   "invalid credentials: token is not valid yet",
+		},
+		{
+			name:  "no expiration",
+			token: hs256Token(t, hs256, claims(map[string]interface{}{"exp": nil}), testSecret),
+			err:   "invalid credentials: token has no expiration time",
+		},
+		{
+			name:  "wrong issuer",
+			token: hs256Token(t, hs256, claims(map[string]interface{}{"iss": "other"}), testSecret),
+			err:   "invalid credentials: unexpected issuer",
+		},
+		{
+			name:  "wrong audience",
+			token: hs256Token(t, hs256, claims(map[string]interface{}{"aud": "other"}), testSecret),
+			err:   "invalid credentials: unexpected audience",
+		},
+		{
+			name:  "bad signature",
+			token: hs256Token(t, hs256, claims(nil), "other"),
+			err:   "invalid credentials: signature does not match",
+		},
+		{
+			name:  "unsigned",
+			token: segment(t, map[string]interface{}{"alg": "none"}) + "." + segment(t, claims(nil)) + ".",
+			err:   `invalid credentials: unsupported algorithm "none"`,
+		},
+		{
+			name:  "no keys for algorithm",
+			token: hs256Token(t, map[string]interface{}{"alg": "RS256"}, claims(nil), testSecret),
+			err:   `invalid credentials: unsupported algorithm "RS256"`,
+		},
+		{
+			name:  "malformed",
+			token: "abc.def",
+			err:   "invalid credentials: malformed token",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := a.Authenticate(bearer(tc.token))
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				assert.True(t, errors.Is(err, ErrInvalidCredentials))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.principal, p)
+		})
+	}
+
+	_, err = a.Authenticate(httptest.NewRequest("GET", "/post", nil))
+	assert.Equal(t, ErrNoCredentials, err)
+}
+
+func TestJWTNamedBySubject(t *testing.T) {
+	a, err := newJWTAuthenticator(config.JWTConfig{Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]interface{}{"sub": "42", "name": "alice", "exp": time.Now().Add(time.Hour).Unix()}
+	p, err := a.Authenticate(bearer(hs256Token(t, map[string]interface{}{"alg": "HS256"}, claims, testSecret)))
+	assert.NoError(t, err)
+	assert.Equal(t, Principal{Subject: "42", Name: "42"}, p)
+}
+
+func TestJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"use": "sig",
+		"kid": "k1",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "jwks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "jwks.json")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := newJWTAuthenticator(config.JWTConfig{JWKSFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]interface{}{"sub": "42", "exp": time.Now().Add(time.Hour).Unix()}
+
+	p, err := a.Authenticate(bearer(rs256Token(t, "k1", claims, key)))
+	assert.NoError(t, err)
+	assert.Equal(t, Principal{Subject: "42", Name: "42"}, p)
+
+	p, err = a.Authenticate(bearer(rs256Token(t, "", claims, key)))
+	assert.NoError(t, err)
+	assert.Equal(t, "42", p.Subject)
+
+	_, err = a.Authenticate(bearer(rs256Token(t, "k1", claims, other)))
+	assert.EqualError(t, err, "invalid credentials: signature does not match")
+
+	_, err = a.Authenticate(bearer(rs256Token(t, "k2", claims, key)))
+	assert.EqualError(t, err, `invalid credentials: unknown key "k2"`)
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = newJWTAuthenticator(config.JWTConfig{JWKSFile: path})
+	assert.Error(t, err)
+}
+
+func TestKeySetLoadsWithoutLock(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	loads := 0
+	ks := &keySet{
+		keys: map[string]*rsa.PublicKey{"k1": &key.PublicKey},
+		load: func() ([]byte, error) {
+			loads++
+			<-release
+			return []byte(`{"keys":[]}`), nil
+		},
+	}
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := ks.key("k2")
+			errs <- err
+		}()
+	}
+	// known key is found while unknown one is being loaded
+	time.Sleep(50 * time.Millisecond)
+	found, err := ks.key("k1")
+	assert.NoError(t, err)
+	assert.Equal(t, &key.PublicKey, found)
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		assert.EqualError(t, <-errs, `invalid credentials: unknown key "k2"`)
+	}
+	assert.Equal(t, 1, loads)
+}
+
+func TestAPIKey(t *testing.T) {
+	a := newAPIKeyAuthenticator([]config.APIKeyConfig{{Key: "key1", Name: "reporter", Roles: []string{"admin"}}})
+
+	r := httptest.NewRequest("GET", "/post", nil)
+	_, err := a.Authenticate(r)
+	assert.Equal(t, ErrNoCredentials, err)
+
+	r.Header.Set("X-API-Key", "key1")
+	p, err := a.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Equal(t, Principal{Subject: "apikey:reporter", Name: "reporter", Roles: []string{"admin"}}, p)
+
+	r.Header.Set("X-API-Key", "key2")
+	_, err = a.Authenticate(r)
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	log := mocks.NewMockLogger(mockCtrl)
+	log.EXPECT().Printf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	authenticators, err := New(config.AuthConfig{APIKeys: []config.APIKeyConfig{{Key: "key1", Name: "reporter"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := Middleware(log, authenticators)(Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := FromContext(r.Context())
+		w.Write([]byte(p.Name))
+	})))
+
+	var testCases = []struct {
+		name       string
+		key        string
+		statusCode int
+		body       string
+	}{
+		{name: "authenticated", key: "key1", statusCode: http.StatusOK, body: "reporter"},
+		{name: "invalid key", key: "key2", statusCode: http.StatusUnauthorized, body: "invalid credentials\n"},
+		{name: "anonymous", statusCode: http.StatusUnauthorized, body: "authentication required\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/post", nil)
+			if tc.key != "" {
+				r.Header.Set("X-API-Key", tc.key)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, r)
+			assert.Equal(t, tc.statusCode, rr.Code)
+			assert.Equal(t, tc.body, rr.Body.String())
+			if tc.statusCode == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="posts"`, rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// minKeysRefresh limits how often keys are reloaded when token names unknown key
+	minKeysRefresh = 5 * time.Minute
+	// jwksTimeout bounds fetching of keys from JWKS URL
+	jwksTimeout = 10 * time.Second
+)
+
+// keySet holds RSA public keys of JWKS reloaded when token is signed by unknown key,
+// so rotated keys are picked up without restart
+// Keys are loaded without holding mu, so slow JWKS delays only requests waiting for new keys
+type keySet struct {
+	mu      sync.Mutex
+	load    func() ([]byte, error)
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+	// loading is closed once keys being loaded are stored, nil while keys are not loaded
+	loading chan struct{}
+	err     error
+}
+
+func newFileKeySet(path string) *keySet {
+	return &keySet{load: func() ([]byte, error) { return ioutil.ReadFile(path) }}
+}
+
+func newURLKeySet(url string) *keySet {
+	client := &http.Client{Timeout: jwksTimeout}
+	return &keySet{load: func() ([]byte, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("JWKS %s responded with %s", url, resp.Status)
+		}
+		return ioutil.ReadAll(resp.Body)
+	}}
+}
+
+// key return key by id, token without key id may be signed by the only key of set
+func (ks *keySet) key(kid string) (*rsa.PublicKey, error) {
+	ks.mu.Lock()
+	key, ok := lookupKey(ks.keys, kid)
+	recent := ks.loading == nil && !ks.fetched.IsZero() && time.Since(ks.fetched) < minKeysRefresh
+	ks.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if recent {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidCredentials, kid)
+	}
+
+	keys, err := ks.refresh()
+	if err != nil {
+		return nil, fmt.Errorf("%w: keys are not available: %v", ErrInvalidCredentials, err)
+	}
+	if key, ok := lookupKey(keys, kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidCredentials, kid)
+}
+
+// refresh reloads keys, callers arriving while keys are loaded wait for the same load
+func (ks *keySet) refresh() (map[string]*rsa.PublicKey, error) {
+	ks.mu.Lock()
+	if loading := ks.loading; loading != nil {
+		ks.mu.Unlock()
+		<-loading
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		return ks.keys, ks.err
+	}
+	loading := make(chan struct{})
+	ks.loading = loading
+	ks.fetched = time.Now()
+	ks.mu.Unlock()
+
+	keys, err := ks.fetch()
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if err == nil {
+		ks.keys = keys
+	}
+	ks.err = err
+	ks.loading = nil
+	close(loading)
+	return keys, err
+}
+
+// fetch loads and parses keys
+func (ks *keySet) fetch() (map[string]*rsa.PublicKey, error) {
+	data, err := ks.load()
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
+
+func lookupKey(keys map[string]*rsa.PublicKey, kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	key, ok := keys[kid]
+	return key, ok
+}
+
+// parseJWKS return RSA signing keys of JSON Web Key Set by key id
+func parseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS: modulus of key %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS: exponent of key %q: %w", k.Kid, err)
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid JWKS: exponent of key %q is out of range", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}
+	}
+	return keys, nil
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+)
+
+const (
+	// defaultNameClaim is subject, display names are not unique, so their posts would be listed together
+	defaultNameClaim  = "sub"
+	defaultRolesClaim = "roles"
+	// clockSkew is tolerated difference between clocks of token issuer and service
+	clockSkew = time.Minute
+)
+
+// jwtAuthenticator authenticates clients by bearer JWTs signed with HS256 or RS256
+type jwtAuthenticator struct {
+	conf config.JWTConfig
+	keys *keySet
+	now  func() time.Time
+}
+
+func newJWTAuthenticator(conf config.JWTConfig) (*jwtAuthenticator, error) {
+	if conf.NameClaim == "" {
+		conf.NameClaim = defaultNameClaim
+	}
+	if conf.RolesClaim == "" {
+		conf.RolesClaim = defaultRolesClaim
+	}
+	a := &jwtAuthenticator{conf: conf, now: time.Now}
+	switch {
+	case conf.JWKSFile != "":
+		a.keys = newFileKeySet(conf.JWKSFile)
+		// broken key file is reported at start rather than on every request
+		if _, err := a.keys.refresh(); err != nil {
+			return nil, err
+		}
+	case conf.JWKSURL != "":
+		a.keys = newURLKeySet(conf.JWKSURL)
+	}
+	return a, nil
+}
+
+// Authenticate return principal of bearer token from Authorization header
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	header := r.Header.Get("Authorization")
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return Principal{}, ErrNoCredentials
+	}
+	return a.verify(strings.TrimSpace(header[len("Bearer "):]))
+}
+
+// verify checks token signature and claims and return its principal
+func (a *jwtAuthenticator) verify(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+	if err := a.checkSignature(header.Alg, header.Kid, parts[0]+"."+parts[1], sig); err != nil {
+		return Principal{}, err
+	}
+
+	claims := map[string]interface{}{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, err
+	}
+	return a.principal(claims)
+}
+
+// checkSignature verifies signature of signed part of token by algorithm from token header,
+// algorithm must have key configured, so unsigned tokens are never accepted
+func (a *jwtAuthenticator) checkSignature(alg, kid, signed string, sig []byte) error {
+	switch alg {
+	case "HS256":
+		if a.conf.Secret == "" {
+			break
+		}
+		mac := hmac.New(sha256.New, []byte(a.conf.Secret))
+		mac.Write([]byte(signed))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return fmt.Errorf("%w: signature does not match", ErrInvalidCredentials)
+		}
+		return nil
+	case "RS256":
+		if a.keys == nil {
+			break
+		}
+		key, err := a.keys.key(kid)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("%w: signature does not match", ErrInvalidCredentials)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidCredentials, alg)
+}
+
+// principal checks registered claims and return principal named by them
+func (a *jwtAuthenticator) principal(claims map[string]interface{}) (Principal, error) {
+	now := a.now()
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: token has no expiration time", ErrInvalidCredentials)
+	}
+	if now.After(time.Unix(exp, 0).Add(clockSkew)) {
+		return Principal{}, fmt.Errorf("%w: token is expired", ErrInvalidCredentials)
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(clockSkew).Before(time.Unix(nbf, 0)) {
+		return Principal{}, fmt.Errorf("%w: token is not valid yet", ErrInvalidCredentials)
+	}
+	if a.conf.Issuer != "" && claims["iss"] != a.conf.Issuer {
+		return Principal{}, fmt.Errorf("%w: unexpected issuer", ErrInvalidCredentials)
+	}
+	if a.conf.Audience != "" && !contains(stringsClaim(claims, "aud"), a.conf.Audience) {
+		return Principal{}, fmt.Errorf("%w: unexpected audience", ErrInvalidCredentials)
+	}
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return Principal{}, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+
+	p := Principal{Subject: sub, Name: sub, Roles: stringsClaim(claims, a.conf.RolesClaim)}
+	if name, _ := claims[a.conf.NameClaim].(string); name != "" {
+		p.Name = name
+	}
+	return p, nil
+}
+
+// decodeSegment unmarshal base64url encoded JSON segment of token
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// numericClaim return claim holding seconds since epoch
+func numericClaim(claims map[string]interface{}, name string) (int64, bool) {
+	n, ok := claims[name].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	if v, err := n.Int64(); err == nil {
+		return v, true
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// stringsClaim return claim holding string or list of strings, space separated string is split
+func stringsClaim(claims map[string]interface{}, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		resp := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				resp = append(resp, s)
+			}
+		}
+		return resp
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
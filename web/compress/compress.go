@@ -0,0 +1,142 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/andybalholm/brotli"
+)
+
+// Content codings of compressed responses
+const (
+	Brotli = "br"
+	Gzip   = "gzip"
+)
+
+// defaultMinBytes is size of the smallest compressed response when it is not configured
+const defaultMinBytes = 1024
+
+// codings holds supported content codings in order of server preference
+var codings = []string{Brotli, Gzip}
+
+// writer is compressing writer which can be reused for another destination
+type writer interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// Compressor compresses responses by content coding accepted by client
+type Compressor struct {
+	minBytes int
+	pools    map[string]*sync.Pool
+}
+
+// New return Compressor of configuration
+func New(conf config.CompressionConfig) (*Compressor, error) {
+	minBytes := conf.MinBytes
+	if minBytes <= 0 {
+		minBytes = defaultMinBytes
+	}
+	gzipLevel := conf.GzipLevel
+	if gzipLevel == 0 {
+		gzipLevel = gzip.DefaultCompression
+	} else if gzipLevel < gzip.BestSpeed || gzipLevel > gzip.BestCompression {
+		return nil, fmt.Errorf("invalid gzip level %d", conf.GzipLevel)
+	}
+	brotliQuality := conf.BrotliQuality
+	if brotliQuality == 0 {
+		brotliQuality = brotli.DefaultCompression
+	}
+	if brotliQuality < brotli.BestSpeed || brotliQuality > brotli.BestCompression {
+		return nil, fmt.Errorf("invalid brotli quality %d", conf.BrotliQuality)
+	}
+
+	return &Compressor{
+		minBytes: minBytes,
+		pools: map[string]*sync.Pool{
+			Gzip: {New: func() interface{} {
+				w, _ := gzip.NewWriterLevel(nil, gzipLevel)
+				return w
+			}},
+			Brotli: {New: func() interface{} { return brotli.NewWriterLevel(nil, brotliQuality) }},
+		},
+	}, nil
+}
+
+// Negotiate return the most preferred coding of Accept-Encoding header value, empty if client
+// accepts none of them. Codings of equal quality are picked in order of server preference
+func Negotiate(header string) string {
+	quality := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if v, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		quality[name] = q
+	}
+
+	accepted := []string{}
+	for _, c := range codings {
+		q, ok := quality[c]
+		if !ok {
+			q, ok = quality["*"]
+		}
+		if ok && q > 0 {
+			accepted = append(accepted, c)
+			quality[c] = q
+		}
+	}
+	if len(accepted) == 0 {
+		return ""
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return quality[accepted[i]] > quality[accepted[j]] })
+	return accepted[0]
+}
+
+// Encode return body compressed by coding and coding used, bodies smaller than configured
+// minimum are returned as is without coding
+func (c *Compressor) Encode(coding string, body []byte) ([]byte, string, error) {
+	if coding == "" || len(body) < c.minBytes {
+		return body, "", nil
+	}
+	buf := &bytes.Buffer{}
+	w := c.writer(coding, buf)
+	defer c.release(coding, w)
+	if _, err := w.Write(body); err != nil {
+		return nil, "", err
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), coding, nil
+}
+
+// writer return pooled writer of coding compressing into dst
+func (c *Compressor) writer(coding string, dst io.Writer) writer {
+	w := c.pools[coding].Get().(writer)
+	w.Reset(dst)
+	return w
+}
+
+// release returns writer of coding to pool
+func (c *Compressor) release(coding string, w writer) {
+	w.Reset(nil)
+	c.pools[coding].Put(w)
+}
@@ -0,0 +1,193 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(config.CompressionConfig{})
+	assert.NoError(t, err)
+	_, err = New(config.CompressionConfig{GzipLevel: 10})
+	assert.Error(t, err)
+	_, err = New(config.CompressionConfig{BrotliQuality: 12})
+	assert.Error(t, err)
+}
+
+func TestNegotiate(t *testing.T) {
+	var testCases = []struct {
+		header string
+		coding string
+	}{
+		{header: "", coding: ""},
+		{header: "identity", coding: ""},
+		{header: "gzip", coding: Gzip},
+		{header: "gzip, deflate, br", coding: Brotli},
+		{header: "br;q=0.5, gzip", coding: Gzip},
+		{header: "GZIP;q=0.8, br;q=0", coding: Gzip},
+		{header: "*", coding: Brotli},
+		{header: "*;q=0.1, br;q=0", coding: Gzip},
+		{header: "gzip;q=0", coding: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.header, func(t *testing.T) {
+			assert.Equal(t, tc.coding, Negotiate(tc.header))
+		})
+	}
+}
+
+func decode(t *testing.T, coding string, body []byte) []byte {
+	var (
+		data []byte
+		err  error
+	)
+	switch coding {
+	case Gzip:
+		r, gzErr := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, gzErr)
+		data, err = ioutil.ReadAll(r)
+	case Brotli:
+		data, err = ioutil.ReadAll(brotli.NewReader(bytes.NewReader(body)))
+	default:
+		data = body
+	}
+	require.NoError(t, err)
+	return data
+}
+
+func TestEncode(t *testing.T) {
+	c, err := New(config.CompressionConfig{MinBytes: 10})
+	require.NoError(t, err)
+	body := []byte(strings.Repeat("post body ", 50))
+	for _, coding := range codings {
+		t.Run(coding, func(t *testing.T) {
+			// writers are pooled, encode twice to reuse one
+			for i := 0; i < 2; i++ {
+				encoded, used, err := c.Encode(coding, body)
+				require.NoError(t, err)
+				assert.Equal(t, coding, used)
+				assert.Less(t, len(encoded), len(body))
+				assert.Equal(t, body, decode(t, coding, encoded))
+			}
+		})
+	}
+	t.Run("small body", func(t *testing.T) {
+		encoded, used, err := c.Encode(Gzip, []byte("short"))
+		require.NoError(t, err)
+		assert.Empty(t, used)
+		assert.Equal(t, []byte("short"), encoded)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	large := strings.Repeat("post body ", 50)
+	type (
+		payload struct {
+			method         string
+			acceptEncoding string
+			body           string
+			headers        map[string]string
+			status         int
+		}
+		expected struct {
+			coding string
+		}
+	)
+	var testCases = []struct {
+		name     string
+		payload  payload
+		expected expected
+	}{
+		{
+			name:     "large body",
+			payload:  payload{acceptEncoding: "gzip, br", body: large},
+			expected: expected{coding: Brotli},
+		},
+		{
+			name:     "weak etag",
+			payload:  payload{acceptEncoding: "gzip", body: large, headers: map[string]string{"ETag": `W/"1"`}},
+			expected: expected{coding: Gzip},
+		},
+		{
+			name:    "small body",
+			payload: payload{acceptEncoding: "gzip", body: "short"},
+		},
+		{
+			name:    "not accepted",
+			payload: payload{body: large},
+		},
+		{
+			name:    "head",
+			payload: payload{method: http.MethodHead, acceptEncoding: "gzip"},
+		},
+		{
+			name:    "strong etag",
+			payload: payload{acceptEncoding: "gzip", body: large, headers: map[string]string{"ETag": `"1"`}},
+		},
+		{
+			name:    "not modified",
+			payload: payload{acceptEncoding: "gzip", status: http.StatusNotModified},
+		},
+	}
+
+	c, err := New(config.CompressionConfig{MinBytes: 100})
+	require.NoError(t, err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tc.payload.headers {
+					w.Header().Set(k, v)
+				}
+				if tc.payload.status != 0 {
+					w.WriteHeader(tc.payload.status)
+				}
+				// body is written in parts to cross minimum size while buffering
+				for _, part := range strings.SplitAfter(tc.payload.body, " ") {
+					w.Write([]byte(part))
+				}
+			}))
+			method := tc.payload.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/posts", nil)
+			req.Header.Set("Accept-Encoding", tc.payload.acceptEncoding)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			status := tc.payload.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			assert.Equal(t, status, rr.Code)
+			assert.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+			assert.Equal(t, tc.expected.coding, rr.Header().Get("Content-Encoding"))
+			assert.Equal(t, tc.payload.body, string(decode(t, tc.expected.coding, rr.Body.Bytes())))
+		})
+	}
+
+	t.Run("encoded by handler", func(t *testing.T) {
+		encoded, _, err := c.Encode(Gzip, []byte(large))
+		require.NoError(t, err)
+		h := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", Gzip)
+			w.Write(encoded)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set("Accept-Encoding", "br, gzip")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		assert.Equal(t, Gzip, rr.Header().Get("Content-Encoding"))
+		assert.Equal(t, encoded, rr.Body.Bytes())
+	})
+}
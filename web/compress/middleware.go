@@ -0,0 +1,106 @@
+package compress
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Middleware compresses responses by coding negotiated from Accept-Encoding
+// Responses are buffered until they reach minimum size, so smaller ones are sent as is.
+// Responses already encoded by handler, responses without body and responses carrying strong
+// ETag are not compressed, strong tags identify bytes sent and are compared in If-Match
+func (c *Compressor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		coding := Negotiate(r.Header.Get("Accept-Encoding"))
+		if coding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, c: c, coding: coding, status: http.StatusOK}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter buffers response until it is known whether it is compressed
+type compressWriter struct {
+	http.ResponseWriter
+	c       *Compressor
+	coding  string
+	status  int
+	buf     []byte
+	started bool
+	w       writer
+}
+
+// WriteHeader keeps status until headers of response are decided
+func (cw *compressWriter) WriteHeader(status int) {
+	if !cw.started {
+		cw.status = status
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.started {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.c.minBytes {
+			return len(p), nil
+		}
+		if err := cw.start(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.w != nil {
+		return cw.w.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// start writes headers and buffered body, compressed if response may be
+func (cw *compressWriter) start(compress bool) error {
+	cw.started = true
+	h := cw.Header()
+	if compress && cw.compressible(h) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.coding)
+		cw.w = cw.c.writer(cw.coding, cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	buf := cw.buf
+	cw.buf = nil
+	if cw.w != nil {
+		_, err := cw.w.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// compressible reports whether response of headers h may be compressed
+func (cw *compressWriter) compressible(h http.Header) bool {
+	if cw.status < http.StatusOK || cw.status == http.StatusNoContent ||
+		cw.status == http.StatusPartialContent || cw.status == http.StatusNotModified {
+		return false
+	}
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	etag := h.Get("ETag")
+	return etag == "" || strings.HasPrefix(etag, "W/")
+}
+
+// close sends response smaller than minimum size as is and finishes compressed one
+func (cw *compressWriter) close() {
+	if !cw.started {
+		cw.start(false)
+	}
+	if cw.w != nil {
+		cw.w.Close()
+		cw.c.release(cw.coding, cw.w)
+	}
+}
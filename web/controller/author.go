@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/PostService/web/encoder"
+)
+
+// GetAuthors return known authors
+// /authors:
+//     get:
+//       tags:
+//         - developers
+//       summary: return list of authors ordered by name
+//       operationId: getAuthors
+//       description: |
+//         Authors with amount of their posts and date of the latest post,
+//         total amount is in X-Total-Count header
+//       parameters:
+//         - in: query
+//           name: prefix
+//           description: beginning of author name, case insensitive
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
+//           name: offset
+//           description: amount of authors to skip
+//           required: false
+//           schema:
+//             type: integer
+//         - in: query
+//           name: limit
+//           description: maximum amount of authors
+//           required: false
+//           schema:
+//             type: integer
+//       responses:
+//         '200':
+//           description: authors matching criteria
+//           content:
+//             application/json:
+//               schema:
+//                 type: array
+//                 items:
+//                   $ref: '#/components/schemas/Author'
+//         '400':
+//           description: bad input parameter
+//         '406':
+//           description: none of supported media types is acceptable
+//         '500':
+//           description: service error
+func (pc *PostController) GetAuthors(w http.ResponseWriter, r *http.Request) {
+	if !encoder.Accepts(r.Header.Get("Accept"), encoder.MediaJSON) {
+		http.Error(w, encoder.ErrNotAcceptable.Error(), http.StatusNotAcceptable)
+		return
+	}
+	qParams := r.URL.Query()
+	offset, limit, err := parsePage(qParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	authors, total, err := pc.postSvc.GetAuthors(qParams.Get("prefix"), offset, limit)
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	pc.writeJSON(w, authors)
+}
+
+// writeJSON marshal value into response as JSON
+func (pc *PostController) writeJSON(w http.ResponseWriter, v interface{}) {
+	response, err := json.Marshal(v)
+	if err != nil {
+		pc.log.Error(err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", encoder.MediaJSON)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(response); err != nil {
+		pc.log.Error(err.Error())
+		return
+	}
+}
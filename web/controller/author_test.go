@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/mocks"
+	"github.com/PostService/model"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAuthors(t *testing.T) {
+	type (
+		payload struct {
+			mockPostSvc func(mock *mocks.MockService)
+			mockLogger  func(mock *mocks.MockLogger)
+			qParams     map[string]string
+			accept      string
+		}
+		expected struct {
+			body       string
+			total      string
+			statusCode int
+		}
+	)
+	var testCases = []struct {
+		name     string
+		payload  payload
+		expected expected
+	}{
+		{
+			name: "not acceptable media type",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {},
+				mockLogger:  func(mock *mocks.MockLogger) {},
+				accept:      "text/csv",
+			},
+			expected: expected{
+				body:       "none of the supported media types is acceptable\n",
+				statusCode: http.StatusNotAcceptable,
+			},
+		},
+		{
+			name: "get authors error",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetAuthors("al", int64(0), int64(defaultPageLimit)).Return(nil, int64(0), errors.New("custom error"))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+					mock.EXPECT().Error("custom error")
+				},
+				qParams: map[string]string{"prefix": "al"},
+			},
+			expected: expected{
+				body:       "custom error\n",
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+		{
+			name: "success",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					latest := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+					authors := []model.Author{{Name: "Alice", Posts: 3, LatestPost: latest}}
+					mock.EXPECT().GetAuthors("al", int64(0), int64(1)).Return(authors, int64(2), nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				qParams:    map[string]string{"prefix": "al", "limit": "1"},
+			},
+			expected: expected{
+				body:       `[{"author":"Alice","posts":3,"latest_post":"02.01.20"}]`,
+				total:      "2",
+				statusCode: http.StatusOK,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := setParams("/authors", tc.payload.qParams)
+			if path == "" {
+				path = "/authors"
+			}
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", tc.payload.accept)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockPostSvc := mocks.NewMockService(mockCtrl)
+			tc.payload.mockPostSvc(mockPostSvc)
+			mockLogger := mocks.NewMockLogger(mockCtrl)
+			tc.payload.mockLogger(mockLogger)
+
+			r := mux.NewRouter()
+			pc := NewPostController(mockLogger, mockPostSvc, config.HTTPCacheConfig{}, config.RequestsConfig{}, nil)
+			rr := httptest.NewRecorder()
+			r.HandleFunc("/authors", pc.GetAuthors).Methods("GET")
+			r.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expected.statusCode, rr.Code)
+			assert.Equal(t, tc.expected.body, rr.Body.String())
+			assert.Equal(t, tc.expected.total, rr.Header().Get("X-Total-Count"))
+		})
+	}
+}
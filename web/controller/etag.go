@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PostService/internal/post"
+	"github.com/PostService/model"
+	"github.com/PostService/web/encoder"
+	"github.com/gorilla/mux"
+)
+
+// varyCredentials lists request headers carrying credentials of viewer
+const varyCredentials = "Authorization, X-API-Key"
+
+// GetPost return post object
+// /post/{id}:
+//     get:
+//       tags:
+//         - developers
+//       summary: return post by identifier
+//       operationId: getPost
+//       description: |
+//         ETag header holds post version, which must be sent in If-Match
+//         header to update or delete the post. If-None-Match and If-Modified-Since
+//         are honoured, so unchanged post is answered by 304. Drafts and scheduled
+//         posts are found for their author only
+//       parameters:
+//         - in: path
+//           name: id
+//           description: post identifier
+//           required: true
+//           schema:
+//             type: string
+//       responses:
+//         '200':
+//           description: post
+//           content:
+//             application/json:
+//               schema:
+//                 $ref: '#/components/schemas/Post'
+//         '304':
+//           description: post is not modified
+//         '404':
+//           description: post not found
+//         '406':
+//           description: none of supported media types is acceptable
+//         '500':
+//           description: service error
+func (pc *PostController) GetPost(w http.ResponseWriter, r *http.Request) {
+	if !encoder.Accepts(r.Header.Get("Accept"), encoder.MediaJSON) {
+		http.Error(w, encoder.ErrNotAcceptable.Error(), http.StatusNotAcceptable)
+		return
+	}
+	id := mux.Vars(r)["id"]
+	p, err := pc.postSvc.GetPost(id, viewer(r))
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+	v, err := pc.postSvc.PostVersion(id)
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+	// drafts are found for their author only, so shared caches must tell clients apart
+	w.Header().Add("Vary", varyCredentials)
+	if pc.notModified(w, r, postETag(p.Revision), v.Modified) {
+		return
+	}
+
+	pc.writeJSON(w, &p)
+}
+
+// checkListVersion answers request for posts matching query by 304 if they did not
+// change since client has read them, otherwise sets caching headers of the list and return its version
+// Version is read before posts, so concurrently changed list is never cached as older one.
+// Lists include unpublished posts their viewer may see, so tag of the list is bound to viewer
+func (pc *PostController) checkListVersion(w http.ResponseWriter, r *http.Request, enc encoder.Encoder, q model.Query) (model.Version, bool) {
+	v, err := pc.postSvc.Version(q)
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return model.Version{}, true
+	}
+	w.Header().Add("Vary", "Accept, "+varyCredentials)
+	return v, pc.notModified(w, r, listETag(v, enc.ContentType(), q.Viewer), v.Modified)
+}
+
+// notModified sets caching headers of current representation and writes 304 if request
+// preconditions show that client has it already. As RFC 7232 requires,
+// If-Modified-Since is ignored when If-None-Match is sent
+func (pc *PostController) notModified(w http.ResponseWriter, r *http.Request, etag string, modified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if pc.cacheControl != "" {
+		w.Header().Set("Cache-Control", pc.cacheControl)
+	}
+
+	if v := r.Header.Get("If-None-Match"); v != "" {
+		if !etagMatches(v, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || modified.IsZero() || modified.After(since) {
+			return false
+		}
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// listETag return weak entity tag of posts list version in media type read by viewer with its identity and roles,
+// lists of the same version differ by encoding only, so tag is weak
+func listETag(v model.Version, contentType string, viewer model.Viewer) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(contentType))
+	if viewer.Name != "" {
+		// identity and roles decide which unpublished posts viewer sees, so they are part of the tag too
+		roles := append([]string{}, viewer.Roles...)
+		sort.Strings(roles)
+		_, _ = h.Write([]byte("|" + viewer.ID + "|" + viewer.Name + "|" + strings.Join(roles, ",")))
+	}
+	return fmt.Sprintf(`W/"%d-%x"`, v.Counter, h.Sum32())
+}
+
+// etagMatches reports whether If-None-Match header value lists etag using weak comparison
+func etagMatches(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// postETag return strong entity tag of post revision
+func postETag(revision int64) string {
+	return strconv.Quote(strconv.FormatInt(revision, 10))
+}
+
+// parseIfMatch read expected post version from If-Match header value
+func parseIfMatch(v string) (int64, error) {
+	v = strings.TrimSpace(v)
+	switch v {
+	case "":
+		return post.VersionUnknown, nil
+	case "*":
+		return post.VersionAny, nil
+	}
+	// weak tags never match in If-Match, so they fail to unquote as well
+	tag, err := strconv.Unquote(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid If-Match %q", v)
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid If-Match %q", v)
+	}
+	return version, nil
+}
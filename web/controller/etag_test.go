@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/internal/authz"
+	"github.com/PostService/internal/post"
+	"github.com/PostService/mocks"
+	"github.com/PostService/model"
+	"github.com/PostService/web/auth"
+	"github.com/PostService/web/encoder"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	var testCases = []struct {
+		value   string
+		version int64
+		valid   bool
+	}{
+		{value: "", version: post.VersionUnknown, valid: true},
+		{value: "*", version: post.VersionAny, valid: true},
+		{value: `"3"`, version: 3, valid: true},
+		{value: `W/"3"`},
+		{value: `3`},
+		{value: `"-1"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			version, err := parseIfMatch(tc.value)
+			if !tc.valid {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.version, version)
+		})
+	}
+}
+
+func TestGetPost(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+		mockPostSvc := mocks.NewMockService(mockCtrl)
+		date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockPostSvc.EXPECT().GetPost("1", model.Viewer{}).Return(model.Post{ID: "1", Name: "name1", Date: date, Author: "author1", Revision: 3}, nil)
+		mockPostSvc.EXPECT().PostVersion("1").Return(model.Version{Counter: 2, Modified: date}, nil)
+
+		r := mux.NewRouter()
+		pc := NewPostController(mocks.NewMockLogger(mockCtrl), mockPostSvc, config.HTTPCacheConfig{}, config.RequestsConfig{}, nil)
+		r.HandleFunc("/post/{id:[0-9]+}", pc.GetPost).Methods("GET")
+		req, err := http.NewRequest("GET", "/post/1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+		assert.Equal(t, "Wed, 01 Jan 2020 00:00:00 GMT", rr.Header().Get("Last-Modified"))
+		assert.Equal(t, `{"id":"1","post_name":"name1","author":"author1","revision":3,"date":"01.01.20"}`, rr.Body.String())
+	})
+	t.Run("not found", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		defer mockCtrl.Finish()
+		mockPostSvc := mocks.NewMockService(mockCtrl)
+		mockPostSvc.EXPECT().GetPost("2", model.Viewer{}).Return(model.Post{}, fmt.Errorf("%w: 2", post.ErrNotFound))
+
+		r := mux.NewRouter()
+		pc := NewPostController(mocks.NewMockLogger(mockCtrl), mockPostSvc, config.HTTPCacheConfig{}, config.RequestsConfig{}, nil)
+		r.HandleFunc("/post/{id:[0-9]+}", pc.GetPost).Methods("GET")
+		req, err := http.NewRequest("GET", "/post/2", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, "post not found: 2\n", rr.Body.String())
+	})
+}
+
+func TestConditionalGet(t *testing.T) {
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	version := model.Version{Counter: 7, Modified: modified}
+	jsonETag := listETag(version, encoder.MediaJSON, model.Viewer{})
+	csv, err := encoder.Negotiate(encoder.MediaCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type (
+		payload struct {
+			mockPostSvc func(mock *mocks.MockService)
+			path        string
+			headers     map[string]string
+		}
+		expected struct {
+			statusCode int
+			etag       string
+		}
+	)
+	var testCases = []struct {
+		name     string
+		payload  payload
+		expected expected
+	}{
+		{
+			name: "list not modified by etag",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().Version(model.Query{Author: "author1", Limit: defaultPageLimit}).Return(version, nil)
+				},
+				path:    "/post/author1",
+				headers: map[string]string{"If-None-Match": `"other", ` + jsonETag},
+			},
+			expected: expected{statusCode: http.StatusNotModified, etag: jsonETag},
+		},
+		{
+			name: "list not modified since",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().Version(model.Query{Author: "author1", Limit: defaultPageLimit}).Return(version, nil)
+				},
+				path:    "/post/author1",
+				headers: map[string]string{"If-Modified-Since": "Thu, 02 Jan 2020 03:04:05 GMT"},
+			},
+			expected: expected{statusCode: http.StatusNotModified, etag: jsonETag},
+		},
+		{
+			name: "list modified",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().Version(model.Query{Author: "author1", Limit: defaultPageLimit}).Return(version, nil)
+					mock.EXPECT().Find(model.Query{Author: "author1", Limit: defaultPageLimit}).
+						Return([]model.Post{{Name: "name1", Author: "author1", Date: modified}}, int64(1), nil)
+				},
+				path: "/post/author1",
+				headers: map[string]string{
+					"If-None-Match":     `W/"6-0"`,
+					"If-Modified-Since": "Thu, 02 Jan 2020 03:04:05 GMT",
+				},
+			},
+			expected: expected{statusCode: http.StatusOK, etag: jsonETag},
+		},
+		{
+			name: "list in other media type",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().Version(model.Query{Author: "author1", Limit: defaultPageLimit}).Return(version, nil)
+					mock.EXPECT().Find(model.Query{Author: "author1", Limit: defaultPageLimit}).
+						Return([]model.Post{{Name: "name1", Author: "author1", Date: modified}}, int64(1), nil)
+				},
+				path: "/post/author1",
+				headers: map[string]string{
+					"Accept":        encoder.MediaCSV,
+					"If-None-Match": jsonETag,
+				},
+			},
+			expected: expected{statusCode: http.StatusOK, etag: listETag(version, csv.ContentType(), model.Viewer{})},
+		},
+		{
+			name: "post not modified",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetPost("1", model.Viewer{}).Return(model.Post{ID: "1", Revision: 3}, nil)
+					mock.EXPECT().PostVersion("1").Return(version, nil)
+				},
+				path:    "/post/1",
+				headers: map[string]string{"If-None-Match": `"3"`},
+			},
+			expected: expected{statusCode: http.StatusNotModified, etag: `"3"`},
+		},
+		{
+			name: "post modified since",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetPost("1", model.Viewer{}).Return(model.Post{ID: "1", Revision: 3}, nil)
+					mock.EXPECT().PostVersion("1").Return(version, nil)
+				},
+				path:    "/post/1",
+				headers: map[string]string{"If-Modified-Since": "Thu, 02 Jan 2020 03:04:04 GMT"},
+			},
+			expected: expected{statusCode: http.StatusOK, etag: `"3"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tc.payload.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tc.payload.headers {
+				req.Header.Set(k, v)
+			}
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockPostSvc := mocks.NewMockService(mockCtrl)
+			tc.payload.mockPostSvc(mockPostSvc)
+
+			r := mux.NewRouter()
+			pc := NewPostController(mocks.NewMockLogger(mockCtrl), mockPostSvc, config.HTTPCacheConfig{CacheControl: "no-cache"}, config.RequestsConfig{}, nil)
+			r.HandleFunc("/post/{id:[0-9]+}", pc.GetPost).Methods("GET")
+			r.HandleFunc("/post/{author}", pc.GetPostsByAuthor).Methods("GET")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expected.statusCode, rr.Code)
+			assert.Equal(t, tc.expected.etag, rr.Header().Get("ETag"))
+			assert.Equal(t, "Thu, 02 Jan 2020 03:04:05 GMT", rr.Header().Get("Last-Modified"))
+			assert.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+			assert.Contains(t, rr.Header().Get("Vary"), "Authorization, X-API-Key")
+			if tc.expected.statusCode == http.StatusNotModified {
+				assert.Empty(t, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestListETagOfViewer(t *testing.T) {
+	version := model.Version{Counter: 7}
+	alice := model.Viewer{ID: "alice", Name: "alice", Roles: []string{authz.RoleWriter}}
+	bob := model.Viewer{ID: "bob", Name: "bob", Roles: []string{authz.RoleWriter}}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPostSvc := mocks.NewMockService(mockCtrl)
+	mockPostSvc.EXPECT().Version(model.Query{Author: "alice", Limit: defaultPageLimit, Viewer: alice}).Return(version, nil)
+	mockPostSvc.EXPECT().Version(model.Query{Author: "alice", Limit: defaultPageLimit, Viewer: bob}).Return(version, nil).Times(2)
+	mockPostSvc.EXPECT().Find(model.Query{Author: "alice", Limit: defaultPageLimit, Viewer: bob}).Return([]model.Post{}, int64(0), nil)
+
+	r := mux.NewRouter()
+	pc := NewPostController(mocks.NewMockLogger(mockCtrl), mockPostSvc, config.HTTPCacheConfig{}, config.RequestsConfig{}, nil)
+	r.HandleFunc("/post/{author}", pc.GetPostsByAuthor).Methods("GET")
+	serve := func(v model.Viewer, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/post/alice", nil)
+		req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{Subject: v.ID, Name: v.Name, Roles: v.Roles}))
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	aliceETag := serve(alice, "*").Header().Get("ETag")
+	assert.Equal(t, listETag(version, encoder.MediaJSON, alice), aliceETag)
+	// list with own drafts of alice is never confirmed to bob
+	rr := serve(bob, aliceETag)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, aliceETag, rr.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, serve(bob, rr.Header().Get("ETag")).Code)
+}
+
+func TestListETagOfRoles(t *testing.T) {
+	version := model.Version{Counter: 7}
+	writer := model.Viewer{Name: "alice", Roles: []string{authz.RoleWriter}}
+	editor := model.Viewer{Name: "alice", Roles: []string{authz.RoleEditor, authz.RoleWriter}}
+	assert.NotEqual(t, listETag(version, encoder.MediaJSON, writer), listETag(version, encoder.MediaJSON, editor))
+	reordered := model.Viewer{Name: "alice", Roles: []string{authz.RoleWriter, authz.RoleEditor}}
+	assert.Equal(t, listETag(version, encoder.MediaJSON, editor), listETag(version, encoder.MediaJSON, reordered))
+	assert.Equal(t, []string{authz.RoleWriter, authz.RoleEditor}, reordered.Roles)
+	namesake := model.Viewer{ID: "apikey:alice", Name: "alice", Roles: []string{authz.RoleWriter}}
+	assert.NotEqual(t, listETag(version, encoder.MediaJSON, writer), listETag(version, encoder.MediaJSON, namesake))
+}
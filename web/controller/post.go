@@ -1,26 +1,63 @@
 package controller
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
-	"sort"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/PostService/infrastructure/breaker"
+	"github.com/PostService/infrastructure/config"
 	"github.com/PostService/infrastructure/logger"
 	"github.com/PostService/internal/post"
 	"github.com/PostService/model"
+	"github.com/PostService/web/auth"
+	"github.com/PostService/web/compress"
+	"github.com/PostService/web/encoder"
 	"github.com/gorilla/mux"
 )
 
-// NewPostController return PostController instance by passing log and post's business logic interface
-func NewPostController(log logger.Logger, postSvc post.Service) *PostController {
-	return &PostController{log: log, postSvc: postSvc}
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// NewPostController return PostController instance by passing log, post's business logic interface,
+// HTTP caching and request bodies configuration and compressor of cached posts lists, which may be nil
+func NewPostController(log logger.Logger, postSvc post.Service, cacheConf config.HTTPCacheConfig,
+	reqConf config.RequestsConfig, compressor *compress.Compressor) *PostController {
+	maxBodyBytes := reqConf.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultMaxBodyBytes
+	}
+	return &PostController{
+		log:                log,
+		postSvc:            postSvc,
+		cacheControl:       cacheConf.CacheControl,
+		maxBodyBytes:       maxBodyBytes,
+		allowUnknownFields: reqConf.AllowUnknownFields,
+		responses:          newResponseCache(cacheConf.ResponseEntries, cacheConf.ResponseMaxBytes),
+		compressor:         compressor,
+	}
 }
 
 // PostController responsible for holding logger and interface for post business logic
 type PostController struct {
-	log     logger.Logger
-	postSvc post.Service
+	log                logger.Logger
+	postSvc            post.Service
+	cacheControl       string
+	maxBodyBytes       int64
+	allowUnknownFields bool
+	// responses caches encoded posts lists, nil if they are encoded for every request
+	responses *responseCache
+	// compressor compresses cached posts lists, nil if responses are not compressed
+	compressor *compress.Compressor
 }
 
 // InsertPost create post record
@@ -37,44 +74,148 @@ type PostController struct {
 //	             schema:
 //	               $ref: '#/components/schemas/Post'
 //	       operationId: insertPost
-//	       description: insert post object
+//	       description: |
+//	         insert post object, post dated in the future is scheduled and listed since its date,
+//	         post with draft status is stored but not listed
 //	       responses:
 //	         '201':
 //	           description: Information stored successfully
 //	         '400':
-//	           description: 'invalid input, object invalid'
+//	           description: 'invalid input, object invalid or exceeds size limits'
+//	         '403':
+//	           description: authenticated client may not create post of this author
+//	         '413':
+//	           description: request body exceeds size limit
+//	         '415':
+//	           description: request body is not application/json
 //	         '500':
 //	           description: service error
 func (pc *PostController) InsertPost(w http.ResponseWriter, r *http.Request) {
-	var post = struct {
-		Name   string `json:"post_name"`
-		Date   string `json:"date"`
-		Author string `json:"author"`
-	}{}
-	if err := json.NewDecoder(r.Body).Decode(&post); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	p, err := pc.decodePost(r)
+	if err != nil {
+		writeRequestError(w, err)
 		pc.log.Error(err.Error())
 		return
 	}
-	t, err := time.Parse("02.01.06", post.Date)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		pc.log.Error(err.Error())
+	if err := pc.postSvc.InsertPost(p, viewer(r)); err != nil {
+		pc.writeServiceError(w, err)
 		return
 	}
-	if err := pc.postSvc.InsertPost(model.Post{Name: post.Name, Date: t, Author: post.Author}); err != nil {
+
+	w.Header().Set("Content-Type", "text/plain")
+	if _, err := w.Write([]byte(`Information stored successfully`)); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		pc.log.Error(err.Error())
 		return
 	}
+	w.WriteHeader(http.StatusOK)
+}
 
+// UpdatePost replace post content keeping previous version as revision
+// /post/{id}:
+//	 put:
+//	       tags:
+//	         - developers
+//	       summary: update post object
+//	       parameters:
+//	         - in: path
+//	           name: id
+//	           description: post identifier
+//	           required: true
+//	           schema:
+//	             type: string
+//	         - in: header
+//	           name: If-Match
+//	           description: ETag of the post version being updated
+//	           required: true
+//	           schema:
+//	             type: string
+//	       requestBody:
+//	         description: post object
+//	         required: true
+//	         content:
+//	           application/json:
+//	             schema:
+//	               $ref: '#/components/schemas/Post'
+//	       operationId: updatePost
+//	       description: update post object, previous version stays available in revisions
+//	       responses:
+//	         '200':
+//	           description: Information stored successfully
+//	         '400':
+//	           description: 'invalid input, object invalid or exceeds size limits'
+//	         '403':
+//	           description: authenticated client may not change post of this author
+//	         '404':
+//	           description: post not found
+//	         '412':
+//	           description: post was changed since ETag from If-Match was received
+//	         '413':
+//	           description: request body exceeds size limit
+//	         '415':
+//	           description: request body is not application/json
+//	         '428':
+//	           description: If-Match header is required
+//	         '500':
+//	           description: service error
+func (pc *PostController) UpdatePost(w http.ResponseWriter, r *http.Request) {
+	p, err := pc.decodePost(r)
+	if err != nil {
+		writeRequestError(w, err)
+		pc.log.Error(err.Error())
+		return
+	}
+	version, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	updated, err := pc.postSvc.UpdatePost(mux.Vars(r)["id"], p, viewer(r), version)
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", postETag(updated.Revision))
 	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write([]byte(`Information stored successfully`)); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		pc.log.Error(err.Error())
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+}
+
+// decodePost read post object from request body
+func (pc *PostController) decodePost(r *http.Request) (model.Post, error) {
+	var payload = struct {
+		Name     string            `json:"post_name"`
+		Date     string            `json:"date"`
+		Author   string            `json:"author"`
+		Summary  string            `json:"summary"`
+		Body     string            `json:"body"`
+		Tags     []string          `json:"tags"`
+		Language string            `json:"language"`
+		Metadata map[string]string `json:"metadata"`
+		Status   string            `json:"status"`
+	}{}
+	if err := pc.readJSON(r, &payload); err != nil {
+		return model.Post{}, err
+	}
+	t, err := time.Parse(model.DateFormat, payload.Date)
+	if err != nil {
+		return model.Post{}, err
+	}
+	return model.Post{
+		Name:     payload.Name,
+		Date:     t,
+		Author:   payload.Author,
+		Summary:  payload.Summary,
+		Body:     payload.Body,
+		Tags:     payload.Tags,
+		Language: payload.Language,
+		Metadata: payload.Metadata,
+		Status:   payload.Status,
+	}, nil
 }
 
 // GetPosts return posts objects
@@ -82,10 +223,13 @@ func (pc *PostController) InsertPost(w http.ResponseWriter, r *http.Request) {
 //     get:
 //       tags:
 //         - developers
-//       summary: return list of posts by post_name or/and author name
+//       summary: return list of posts filtered by post_name, author, date range and tags
 //       operationId: get posts
 //       description: |
-//         By passing in the appropriate options, get posts objects
+//         By passing in the appropriate options, get posts objects, without filters
+//         returns feed of all posts, newest first. Total amount of matched posts
+//         is in X-Total-Count header. Unchanged list is answered by 304 when
+//         If-None-Match or If-Modified-Since is sent
 //       parameters:
 //         - in: query
 //           name: post_name
@@ -100,8 +244,65 @@ func (pc *PostController) InsertPost(w http.ResponseWriter, r *http.Request) {
 //           schema:
 //             type: string
 //         - in: query
+//           name: tag
+//           description: tag for searching, can be repeated
+//           required: false
+//           schema:
+//             type: array
+//             items:
+//               type: string
+//         - in: query
+//           name: tag_mode
+//           description: and (default) returns posts marked by all tags, or by any of them
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
+//           name: from
+//           description: first day of posts date range in dd.mm.yy format
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
+//           name: to
+//           description: last day of posts date range in dd.mm.yy format
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
+//           name: sort
+//           description: date_desc (default) or date_asc
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
 //           name: order
-//           description: does need ordering
+//           description: true orders posts by date_desc, kept for older clients
+//           required: false
+//           deprecated: true
+//           schema:
+//             type: boolean
+//         - in: query
+//           name: offset
+//           description: amount of posts to skip
+//           required: false
+//           schema:
+//             type: integer
+//         - in: query
+//           name: limit
+//           description: maximum amount of posts
+//           required: false
+//           schema:
+//             type: integer
+//         - in: query
+//           name: fields
+//           description: comma separated list of returned post fields
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
+//           name: include_unpublished
+//           description: also return drafts and scheduled posts, allowed for their author and admins only
 //           required: false
 //           schema:
 //             type: boolean
@@ -114,68 +315,38 @@ func (pc *PostController) InsertPost(w http.ResponseWriter, r *http.Request) {
 //                 type: array
 //                 items:
 //                   $ref: '#/components/schemas/Post'
+//             text/csv:
+//               schema:
+//                 type: string
+//             application/xml:
+//               schema:
+//                 type: array
+//                 items:
+//                   $ref: '#/components/schemas/Post'
+//             application/x-ndjson:
+//               schema:
+//                 type: string
+//         '304':
+//           description: posts are not modified
 //         '400':
 //           description: bad input parameter
+//         '403':
+//           description: client may not list unpublished posts
+//         '406':
+//           description: none of supported media types is acceptable
 //         '500':
 //           description: service error
 func (pc *PostController) GetPosts(w http.ResponseWriter, r *http.Request) {
-	var (
-		err   error
-		posts model.Posts
-	)
-	qParams := r.URL.Query()
-	author := qParams.Get("author")
-	name := qParams.Get("post_name")
-	if author != "" && name != "" {
-		posts, err = pc.postSvc.GetPostsByNameAndAuthor(name, author)
-		if err != nil {
-			pc.log.Error(err.Error())
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	} else if author != "" || name != "" {
-		var key string
-		if author != "" {
-			key = author
-		} else if name != "" {
-			key = name
-		}
-		if posts, err = pc.postSvc.GetPostsByKey(key); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			pc.log.Error(err.Error())
-			return
-		}
-	}
-	if len(posts) == 0 {
-		w.Header().Set("Content-Type", "text/plain")
-		if _, err := w.Write([]byte(`No posts found`)); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			pc.log.Error(err.Error())
-			return
-		}
-		w.WriteHeader(http.StatusOK)
+	enc, fields, ok := pc.negotiate(w, r)
+	if !ok {
 		return
 	}
-
-	order := qParams.Get("order")
-	responce := []byte{}
-	if order == "true" {
-		sort.Sort(&posts)
-	}
-	responce, err = json.Marshal(posts)
+	q, err := parseQuery(r.URL.Query())
 	if err != nil {
-		pc.log.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write(responce); err != nil {
-		pc.log.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	pc.listPosts(w, r, enc, fields, q)
 }
 
 // GetPostsByAuthor return posts objects
@@ -186,7 +357,9 @@ func (pc *PostController) GetPosts(w http.ResponseWriter, r *http.Request) {
 //       summary: return list of posts by author name
 //       operationId: getPostsByAuthor
 //       description: |
-//         By passing in the appropriate options, get posts objects
+//         By passing in the appropriate options, get posts objects,
+//         total amount of matched posts is in X-Total-Count header.
+//         Unchanged list is answered by 304 when If-None-Match or If-Modified-Since is sent
 //       parameters:
 //         - in: path
 //           name: author
@@ -195,8 +368,51 @@ func (pc *PostController) GetPosts(w http.ResponseWriter, r *http.Request) {
 //           schema:
 //             type: string
 //         - in: query
+//           name: from
+//           description: first day of posts date range in dd.mm.yy format
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
+//           name: to
+//           description: last day of posts date range in dd.mm.yy format
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
+//           name: sort
+//           description: date_desc (default) or date_asc
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
 //           name: order
-//           description: does need ordering
+//           description: true orders posts by date_desc, kept for older clients
+//           required: false
+//           deprecated: true
+//           schema:
+//             type: boolean
+//         - in: query
+//           name: offset
+//           description: amount of posts to skip
+//           required: false
+//           schema:
+//             type: integer
+//         - in: query
+//           name: limit
+//           description: maximum amount of posts
+//           required: false
+//           schema:
+//             type: integer
+//         - in: query
+//           name: fields
+//           description: comma separated list of returned post fields
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
+//           name: include_unpublished
+//           description: also return drafts and scheduled posts, allowed for their author and admins only
 //           required: false
 //           schema:
 //             type: boolean
@@ -209,15 +425,32 @@ func (pc *PostController) GetPosts(w http.ResponseWriter, r *http.Request) {
 //                 type: array
 //                 items:
 //                   $ref: '#/components/schemas/Post'
+//             text/csv:
+//               schema:
+//                 type: string
+//             application/xml:
+//               schema:
+//                 type: array
+//                 items:
+//                   $ref: '#/components/schemas/Post'
+//             application/x-ndjson:
+//               schema:
+//                 type: string
+//         '304':
+//           description: posts are not modified
 //         '400':
 //           description: bad input parameter
+//         '403':
+//           description: client may not list unpublished posts
+//         '406':
+//           description: none of supported media types is acceptable
 //         '500':
 //           description: service error
 func (pc *PostController) GetPostsByAuthor(w http.ResponseWriter, r *http.Request) {
-	var (
-		err   error
-		posts model.Posts
-	)
+	enc, fields, ok := pc.negotiate(w, r)
+	if !ok {
+		return
+	}
 	author, ok := mux.Vars(r)["author"]
 	if !ok {
 		w.Header().Set("Content-Type", "text/plain")
@@ -229,12 +462,181 @@ func (pc *PostController) GetPostsByAuthor(w http.ResponseWriter, r *http.Reques
 		w.WriteHeader(http.StatusOK)
 		return
 	}
-	posts, err = pc.postSvc.GetPostsByKey(author)
+	q, err := parseQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q.Author = author
+	pc.listPosts(w, r, enc, fields, q)
+}
+
+// SearchPosts return posts which names match search query
+// /post/search:
+//     get:
+//       tags:
+//         - developers
+//       summary: full-text search over post names
+//       operationId: searchPosts
+//       description: |
+//         Case-insensitive search by words of post name, words of the query also match
+//         as prefixes. Results are ordered by relevance, total amount is in X-Total-Count header
+//       parameters:
+//         - in: query
+//           name: q
+//           description: search query
+//           required: true
+//           schema:
+//             type: string
+//         - in: query
+//           name: offset
+//           description: amount of results to skip
+//           required: false
+//           schema:
+//             type: integer
+//         - in: query
+//           name: limit
+//           description: maximum amount of results
+//           required: false
+//           schema:
+//             type: integer
+//         - in: query
+//           name: fields
+//           description: comma separated list of returned post fields
+//           required: false
+//           schema:
+//             type: string
+//       responses:
+//         '200':
+//           description: search results matching criteria
+//           content:
+//             application/json:
+//               schema:
+//                 type: array
+//                 items:
+//                   $ref: '#/components/schemas/Post'
+//         '400':
+//           description: bad input parameter
+//         '406':
+//           description: none of supported media types is acceptable
+//         '500':
+//           description: service error
+func (pc *PostController) SearchPosts(w http.ResponseWriter, r *http.Request) {
+	enc, fields, ok := pc.negotiate(w, r)
+	if !ok {
+		return
+	}
+	qParams := r.URL.Query()
+	query := qParams.Get("q")
+	if query == "" {
+		http.Error(w, "search query is required", http.StatusBadRequest)
+		return
+	}
+	offset, limit, err := parsePage(qParams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	posts, total, err := pc.postSvc.SearchPosts(query, offset, limit)
 	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	pc.writePosts(w, enc, fields, posts)
+}
+
+// listPosts write page of posts matching query which client may see unless client already has them
+func (pc *PostController) listPosts(w http.ResponseWriter, r *http.Request, enc encoder.Encoder, fields []string, q model.Query) {
+	q.Viewer = viewer(r)
+	v, done := pc.checkListVersion(w, r, enc, q)
+	if done {
+		return
+	}
+	if pc.responses == nil {
+		pc.findPosts(w, enc, fields, q)
+		return
+	}
+	pc.cachedPosts(w, r, enc, fields, q, v)
+}
+
+// cachedPosts write page of posts of version v as encoded and compressed before,
+// page which is not cached yet is read and encoded for the next requests
+func (pc *PostController) cachedPosts(w http.ResponseWriter, r *http.Request, enc encoder.Encoder, fields []string,
+	q model.Query, v model.Version) {
+	coding := ""
+	if pc.compressor != nil {
+		coding = compress.Negotiate(r.Header.Get("Accept-Encoding"))
+	}
+	key := responseKey(v, enc, fields, q, coding)
+	res, ok := pc.responses.get(key)
+	if !ok {
+		posts, total, err := pc.postSvc.Find(q)
+		if err != nil {
+			pc.writeServiceError(w, err)
+			return
+		}
+		res = &cachedResponse{key: key, total: total}
+		if res.body, res.contentType, err = encodePosts(enc, fields, posts); err == nil && coding != "" {
+			res.body, res.coding, err = pc.compressor.Encode(coding, res.body)
+		}
+		if err != nil {
+			pc.log.Error(err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		pc.responses.add(res)
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(res.total, 10))
+	w.Header().Set("Content-Type", res.contentType)
+	if res.coding != "" {
+		w.Header().Set("Content-Encoding", res.coding)
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(res.body); err != nil {
 		pc.log.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// responseKey return key of cached response holding page of posts of version v
+// encoded by enc and compressed by coding, version changes with every change of the list
+func responseKey(v model.Version, enc encoder.Encoder, fields []string, q model.Query, coding string) string {
+	query, _ := json.Marshal(q)
+	return fmt.Sprintf("%d|%d|%s|%s|%s|%s", v.Counter, v.Modified.UnixNano(), enc.ContentType(), strings.Join(fields, ","), coding, query)
+}
+
+// encodePosts return requested fields of posts encoded by enc and media type of encoding,
+// empty page is described by text as findPosts does
+func encodePosts(enc encoder.Encoder, fields []string, posts model.Posts) ([]byte, string, error) {
+	if len(posts) == 0 {
+		return []byte(`No posts found`), "text/plain", nil
+	}
+	buf := &bytes.Buffer{}
+	if err := enc.Encode(buf, posts, fields); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), enc.ContentType(), nil
+}
+
+// viewer return authenticated client of request, anonymous when request carries no credentials
+func viewer(r *http.Request) model.Viewer {
+	p, ok := auth.FromContext(r.Context())
+	if !ok {
+		return model.Viewer{}
+	}
+	return model.Viewer{ID: p.Subject, Name: p.Name, Roles: p.Roles}
+}
+
+// findPosts write page of posts matching query with total amount of matched posts
+func (pc *PostController) findPosts(w http.ResponseWriter, enc encoder.Encoder, fields []string, q model.Query) {
+	posts, total, err := pc.postSvc.Find(q)
+	if err != nil {
+		pc.writeServiceError(w, err)
 		return
 	}
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
 	if len(posts) == 0 {
 		w.Header().Set("Content-Type", "text/plain")
 		if _, err := w.Write([]byte(`No posts found`)); err != nil {
@@ -246,24 +648,82 @@ func (pc *PostController) GetPostsByAuthor(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	qParams := r.URL.Query()
-	order := qParams.Get("order")
-	responce := []byte{}
-	if order == "true" {
-		sort.Sort(&posts)
+	pc.writePosts(w, enc, fields, posts)
+}
+
+// parsePage read offset and limit pagination parameters
+func parsePage(qParams url.Values) (offset, limit int64, err error) {
+	limit = defaultPageLimit
+	if v := qParams.Get("offset"); v != "" {
+		if offset, err = strconv.ParseInt(v, 10, 64); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %q", v)
+		}
+	}
+	if v := qParams.Get("limit"); v != "" {
+		if limit, err = strconv.ParseInt(v, 10, 64); err != nil || limit <= 0 || limit > maxPageLimit {
+			return 0, 0, fmt.Errorf("invalid limit %q, must be between 1 and %d", v, maxPageLimit)
+		}
 	}
-	responce, err = json.Marshal(posts)
+	return offset, limit, nil
+}
+
+// negotiate pick response encoder by Accept header and read requested post fields,
+// writes 406 if nothing acceptable and 400 on unknown fields
+func (pc *PostController) negotiate(w http.ResponseWriter, r *http.Request) (encoder.Encoder, []string, bool) {
+	enc, err := encoder.Negotiate(r.Header.Get("Accept"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		pc.log.Error(err.Error())
+		return nil, nil, false
+	}
+	fields, err := encoder.ParseFields(r.URL.Query().Get("fields"))
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+	return enc, fields, true
+}
+
+// writePosts encode requested fields of posts with negotiated encoder into response
+func (pc *PostController) writePosts(w http.ResponseWriter, enc encoder.Encoder, fields []string, posts model.Posts) {
+	buf := &bytes.Buffer{}
+	if err := enc.Encode(buf, posts, fields); err != nil {
 		pc.log.Error(err.Error())
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", enc.ContentType())
 	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write(responce); err != nil {
+	if _, err := w.Write(buf.Bytes()); err != nil {
 		pc.log.Error(err.Error())
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
+
+// writeServiceError writes status matching service error, unexpected errors are logged
+func (pc *PostController) writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, post.ErrInvalidPost), errors.Is(err, post.ErrInvalidQuery):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, post.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, post.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, post.ErrInvalidTransition):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, post.ErrVersionMismatch):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, post.ErrVersionRequired):
+		http.Error(w, err.Error(), http.StatusPreconditionRequired)
+	case errors.Is(err, post.ErrUnavailable):
+		var open *breaker.OpenError
+		if errors.As(err, &open) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(open.RetryAfter.Seconds()))))
+		}
+		http.Error(w, "service is temporarily unavailable", http.StatusServiceUnavailable)
+	default:
+		pc.log.Error(err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -8,8 +8,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/internal/post"
 	"github.com/PostService/mocks"
 	"github.com/PostService/model"
+	"github.com/PostService/web/encoder"
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
@@ -21,10 +24,12 @@ func TestGetPosts(t *testing.T) {
 			mockPostSvc func(mock *mocks.MockService)
 			mockLogger  func(mock *mocks.MockLogger)
 			qParams     map[string]string
+			headers     map[string]string
 			path        string
 		}
 		expected struct {
 			body       string
+			total      string
 			statusCode int
 		}
 	)
@@ -34,10 +39,11 @@ func TestGetPosts(t *testing.T) {
 		expected expected
 	}{
 		{
-			name: "GetPostsByAuthorAndName (post_name and author provided) error",
+			name: "post_name and author provided error",
 			payload: payload{
 				mockPostSvc: func(mock *mocks.MockService) {
-					mock.EXPECT().GetPostsByNameAndAuthor("name1", "author1").Return(nil, errors.New("custom error"))
+					mock.EXPECT().Find(model.Query{Name: "name1", Author: "author1", Limit: defaultPageLimit}).
+						Return(nil, int64(0), errors.New("custom error"))
 				},
 				mockLogger: func(mock *mocks.MockLogger) {
 					mock.EXPECT().Error("custom error")
@@ -54,16 +60,17 @@ func TestGetPosts(t *testing.T) {
 			},
 		},
 		{
-			name: "GetPostsByAuthorAndName only post_name provided error",
+			name: "only author provided error",
 			payload: payload{
 				mockPostSvc: func(mock *mocks.MockService) {
-					mock.EXPECT().GetPostsByKey("name1").Return(nil, errors.New("custom error"))
+					mock.EXPECT().Find(model.Query{Author: "author1", Limit: defaultPageLimit}).
+						Return(nil, int64(0), errors.New("custom error"))
 				},
 				mockLogger: func(mock *mocks.MockLogger) {
 					mock.EXPECT().Error("custom error")
 				},
 				qParams: map[string]string{
-					"post_name": "name1",
+					"author": "author1",
 				},
 				path: "/post",
 			},
@@ -73,87 +80,348 @@ func TestGetPosts(t *testing.T) {
 			},
 		},
 		{
-			name: "GetPostsByAuthorAndName only author provided error",
+			name: "posts lenth is 0 error",
 			payload: payload{
 				mockPostSvc: func(mock *mocks.MockService) {
-					mock.EXPECT().GetPostsByKey("author1").Return(nil, errors.New("custom error"))
+					mock.EXPECT().Find(model.Query{Name: "name1", Limit: defaultPageLimit}).Return([]model.Post{}, int64(0), nil)
 				},
 				mockLogger: func(mock *mocks.MockLogger) {
-					mock.EXPECT().Error("custom error")
 				},
 				qParams: map[string]string{
-					"author": "author1",
+					"post_name": "name1",
 				},
 				path: "/post",
 			},
 			expected: expected{
-				body:       "custom error\n",
-				statusCode: http.StatusInternalServerError,
+				body:       "No posts found",
+				total:      "0",
+				statusCode: http.StatusOK,
 			},
 		},
 		{
-			name: "posts lenth is 0 error",
+			name: "order is true case",
 			payload: payload{
 				mockPostSvc: func(mock *mocks.MockService) {
-					mock.EXPECT().GetPostsByKey("name1").Return([]model.Post{}, nil)
+					date1 := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
+					date2 := time.Date(2000, 1, 1, 1, 1, 1, 1, time.UTC)
+					posts := []model.Post{{Name: "name1", Date: date1, Author: "author1"},
+						{Name: "name1", Date: date2, Author: "author2"}}
+					mock.EXPECT().Find(model.Query{Name: "name1", Sort: model.SortDateDesc, Limit: defaultPageLimit}).
+						Return(posts, int64(2), nil)
 				},
 				mockLogger: func(mock *mocks.MockLogger) {
 				},
 				qParams: map[string]string{
 					"post_name": "name1",
+					"order":     "true",
 				},
 				path: "/post",
 			},
 			expected: expected{
-				body:       "No posts found",
+				body:       "[{\"post_name\":\"name1\",\"author\":\"author1\",\"date\":\"01.01.20\"},{\"post_name\":\"name1\",\"author\":\"author2\",\"date\":\"01.01.00\"}]",
+				total:      "2",
 				statusCode: http.StatusOK,
 			},
 		},
 		{
-			name: "order is true case",
+			name: "no order case",
 			payload: payload{
 				mockPostSvc: func(mock *mocks.MockService) {
 					date1 := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
 					date2 := time.Date(2000, 1, 1, 1, 1, 1, 1, time.UTC)
-					posts := []model.Post{{Name: "name2", Date: date2, Author: "author2"},
-						{Name: "name1", Date: date1, Author: "author1"}}
-					mock.EXPECT().GetPostsByKey("name1").Return(posts, nil)
+					posts := []model.Post{{Name: "name1", Date: date1, Author: "author1"},
+						{Name: "name1", Date: date2, Author: "author2"}}
+					mock.EXPECT().Find(model.Query{Name: "name1", Limit: defaultPageLimit}).Return(posts, int64(2), nil)
 				},
 				mockLogger: func(mock *mocks.MockLogger) {
 				},
 				qParams: map[string]string{
 					"post_name": "name1",
-					"order":     "true",
 				},
 				path: "/post",
 			},
 			expected: expected{
-				body:       "[{\"post_name\":\"name1\",\"author\":\"author1\",\"date\":\"01.01.20\"},{\"post_name\":\"name2\",\"author\":\"author2\",\"date\":\"01.01.00\"}]",
+				body:       "[{\"post_name\":\"name1\",\"author\":\"author1\",\"date\":\"01.01.20\"},{\"post_name\":\"name1\",\"author\":\"author2\",\"date\":\"01.01.00\"}]",
+				total:      "2",
 				statusCode: http.StatusOK,
 			},
 		},
 		{
-			name: "no order case",
+			name: "order is true case by author",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().Version(model.Query{Author: "author1", Sort: model.SortDateDesc, Limit: defaultPageLimit}).
+						Return(model.Version{Counter: 1}, nil)
+					mock.EXPECT().Find(model.Query{Author: "author1", Sort: model.SortDateDesc, Limit: defaultPageLimit}).
+						Return([]model.Post{}, int64(0), nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+				},
+				qParams: map[string]string{
+					"order": "true",
+				},
+				path: "/post/author1",
+			},
+			expected: expected{
+				body:       "No posts found",
+				total:      "0",
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "order combined with other sort",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {},
+				mockLogger: func(mock *mocks.MockLogger) {
+				},
+				qParams: map[string]string{
+					"order": "true",
+					"sort":  "date_asc",
+				},
+				path: "/post",
+			},
+			expected: expected{
+				body:       "order=true can not be combined with sort \"date_asc\"\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "date range, sort and page",
 			payload: payload{
 				mockPostSvc: func(mock *mocks.MockService) {
 					date1 := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
-					date2 := time.Date(2000, 1, 1, 1, 1, 1, 1, time.UTC)
-					posts := []model.Post{{Name: "name2", Date: date2, Author: "author2"},
-						{Name: "name1", Date: date1, Author: "author1"}}
-					mock.EXPECT().GetPostsByKey("name1").Return(posts, nil)
+					date2 := time.Date(2020, 1, 2, 1, 1, 1, 1, time.UTC)
+					posts := []model.Post{{Name: "name1", Date: date1, Author: "author1"},
+						{Name: "name1", Date: date2, Author: "author2"}}
+					mock.EXPECT().Find(model.Query{
+						Name:   "name1",
+						From:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+						To:     time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC),
+						Sort:   model.SortDateAsc,
+						Offset: 2,
+						Limit:  2,
+					}).Return(posts, int64(5), nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+				},
+				qParams: map[string]string{
+					"post_name": "name1",
+					"from":      "01.01.20",
+					"to":        "31.01.20",
+					"sort":      "date_asc",
+					"offset":    "2",
+					"limit":     "2",
+				},
+				path: "/post",
+			},
+			expected: expected{
+				body:       "[{\"post_name\":\"name1\",\"author\":\"author1\",\"date\":\"01.01.20\"},{\"post_name\":\"name1\",\"author\":\"author2\",\"date\":\"02.01.20\"}]",
+				total:      "5",
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "feed of all posts",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					date1 := time.Date(2020, 1, 2, 1, 1, 1, 1, time.UTC)
+					date2 := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
+					posts := []model.Post{{ID: "2", Name: "name2", Date: date1, Author: "author2"},
+						{ID: "1", Name: "name1", Date: date2, Author: "author1"}}
+					mock.EXPECT().Find(model.Query{Limit: 2}).Return(posts, int64(3), nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+				},
+				qParams: map[string]string{
+					"limit": "2",
+				},
+				path: "/post",
+			},
+			expected: expected{
+				body:       "[{\"id\":\"2\",\"post_name\":\"name2\",\"author\":\"author2\",\"date\":\"02.01.20\"},{\"id\":\"1\",\"post_name\":\"name1\",\"author\":\"author1\",\"date\":\"01.01.20\"}]",
+				total:      "3",
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "invalid date range",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+				},
+				qParams: map[string]string{
+					"from": "2020-01-01",
+				},
+				path: "/post",
+			},
+			expected: expected{
+				body:       "invalid from \"2020-01-01\", must be in dd.mm.yy format\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "query rejected by service",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().Find(model.Query{Sort: "name", Limit: defaultPageLimit}).
+						Return(nil, int64(0), fmt.Errorf("%w: unknown sort \"name\"", post.ErrInvalidQuery))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+				},
+				qParams: map[string]string{
+					"sort": "name",
+				},
+				path: "/post",
+			},
+			expected: expected{
+				body:       "invalid query: unknown sort \"name\"\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "csv with sparse fields requested",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					date1 := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
+					posts := []model.Post{{Name: "name1", Date: date1, Author: "author1"}}
+					mock.EXPECT().Find(model.Query{Name: "name1", Limit: defaultPageLimit}).Return(posts, int64(1), nil)
 				},
 				mockLogger: func(mock *mocks.MockLogger) {
 				},
 				qParams: map[string]string{
 					"post_name": "name1",
+					"fields":    "post_name,author",
+				},
+				headers: map[string]string{
+					"Accept": "text/csv",
+				},
+				path: "/post",
+			},
+			expected: expected{
+				body:       "post_name,author\nname1,author1\n",
+				total:      "1",
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "posts by author and any of tags",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					date1 := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
+					posts := []model.Post{{Name: "name1", Date: date1, Author: "author1", Tags: []string{"go"}}}
+					mock.EXPECT().Find(model.Query{Author: "author1", Tags: []string{"go"}, AnyTag: true, Limit: defaultPageLimit}).
+						Return(posts, int64(1), nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+				},
+				qParams: map[string]string{
+					"author":   "author1",
+					"tag":      "go",
+					"tag_mode": "or",
 				},
 				path: "/post",
 			},
 			expected: expected{
-				body:       "[{\"post_name\":\"name2\",\"author\":\"author2\",\"date\":\"01.01.00\"},{\"post_name\":\"name1\",\"author\":\"author1\",\"date\":\"01.01.20\"}]",
+				body:       "[{\"post_name\":\"name1\",\"author\":\"author1\",\"tags\":[\"go\"],\"date\":\"01.01.20\"}]",
+				total:      "1",
 				statusCode: http.StatusOK,
 			},
 		},
+		{
+			name: "unpublished posts requested",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().Version(model.Query{Author: "author1", Limit: defaultPageLimit, Unpublished: true}).
+						Return(model.Version{}, fmt.Errorf("%w: anonymous client may not view unpublished posts", post.ErrForbidden))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+				},
+				qParams: map[string]string{
+					"author":              "author1",
+					"include_unpublished": "true",
+				},
+				path: "/post",
+			},
+			expected: expected{
+				body:       "forbidden: anonymous client may not view unpublished posts\n",
+				statusCode: http.StatusForbidden,
+			},
+		},
+		{
+			name: "invalid include_unpublished",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+				},
+				qParams: map[string]string{
+					"include_unpublished": "yes",
+				},
+				path: "/post",
+			},
+			expected: expected{
+				body:       "invalid include_unpublished \"yes\", must be true or false\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "invalid tag mode",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+				},
+				qParams: map[string]string{
+					"tag":      "go",
+					"tag_mode": "xor",
+				},
+				path: "/post",
+			},
+			expected: expected{
+				body:       "invalid tag_mode \"xor\", must be and or or\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "unknown field requested",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+				},
+				qParams: map[string]string{
+					"post_name": "name1",
+					"fields":    "post_name,password",
+				},
+				path: "/post",
+			},
+			expected: expected{
+				body:       "unknown field \"password\"\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "not acceptable media type",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+					mock.EXPECT().Error(encoder.ErrNotAcceptable.Error())
+				},
+				qParams: map[string]string{
+					"post_name": "name1",
+				},
+				headers: map[string]string{
+					"Accept": "image/png",
+				},
+				path: "/post",
+			},
+			expected: expected{
+				body:       encoder.ErrNotAcceptable.Error() + "\n",
+				statusCode: http.StatusNotAcceptable,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -162,20 +430,26 @@ func TestGetPosts(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
+			for k, v := range tc.payload.headers {
+				req.Header.Set(k, v)
+			}
 			mockCtrl := gomock.NewController(t)
 			defer mockCtrl.Finish()
 			mockPostSvc := mocks.NewMockService(mockCtrl)
 			tc.payload.mockPostSvc(mockPostSvc)
+			mockPostSvc.EXPECT().Version(gomock.Any()).Return(model.Version{Counter: 1}, nil).AnyTimes()
 			mockLogger := mocks.NewMockLogger(mockCtrl)
 			tc.payload.mockLogger(mockLogger)
 
 			r := mux.NewRouter()
-			pc := NewPostController(mockLogger, mockPostSvc)
+			pc := NewPostController(mockLogger, mockPostSvc, config.HTTPCacheConfig{}, config.RequestsConfig{}, nil)
 			rr := httptest.NewRecorder()
 			r.HandleFunc("/post", pc.GetPosts).Methods("GET")
+			r.HandleFunc("/post/{author}", pc.GetPostsByAuthor).Methods("GET")
 			r.ServeHTTP(rr, req)
 			assert.Equal(t, tc.expected.statusCode, rr.Code)
 			assert.Equal(t, tc.expected.body, rr.Body.String())
+			assert.Equal(t, tc.expected.total, rr.Header().Get("X-Total-Count"))
 		})
 	}
 }
@@ -194,3 +468,105 @@ func setParams(path string, qParams map[string]string) string {
 	}
 	return path + resp
 }
+
+func TestSearchPosts(t *testing.T) {
+	type (
+		payload struct {
+			mockPostSvc func(mock *mocks.MockService)
+			mockLogger  func(mock *mocks.MockLogger)
+			qParams     map[string]string
+		}
+		expected struct {
+			body       string
+			total      string
+			statusCode int
+		}
+	)
+	var testCases = []struct {
+		name     string
+		payload  payload
+		expected expected
+	}{
+		{
+			name: "no query provided",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {},
+				mockLogger:  func(mock *mocks.MockLogger) {},
+				qParams:     map[string]string{"limit": "5"},
+			},
+			expected: expected{
+				body:       "search query is required\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "invalid limit",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {},
+				mockLogger:  func(mock *mocks.MockLogger) {},
+				qParams:     map[string]string{"q": "golang", "limit": "1000"},
+			},
+			expected: expected{
+				body:       "invalid limit \"1000\", must be between 1 and 100\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "search error",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().SearchPosts("golang", int64(0), int64(defaultPageLimit)).Return(nil, int64(0), errors.New("custom error"))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+					mock.EXPECT().Error("custom error")
+				},
+				qParams: map[string]string{"q": "golang"},
+			},
+			expected: expected{
+				body:       "custom error\n",
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+		{
+			name: "success",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					date := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
+					posts := []model.Post{{ID: "3", Name: "Intro to Golang", Date: date, Author: "author1"}}
+					mock.EXPECT().SearchPosts("golang", int64(10), int64(1)).Return(posts, int64(11), nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				qParams:    map[string]string{"q": "golang", "offset": "10", "limit": "1"},
+			},
+			expected: expected{
+				body:       "[{\"id\":\"3\",\"post_name\":\"Intro to Golang\",\"author\":\"author1\",\"date\":\"01.01.20\"}]",
+				total:      "11",
+				statusCode: http.StatusOK,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", setParams("/post/search", tc.payload.qParams), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockPostSvc := mocks.NewMockService(mockCtrl)
+			tc.payload.mockPostSvc(mockPostSvc)
+			mockLogger := mocks.NewMockLogger(mockCtrl)
+			tc.payload.mockLogger(mockLogger)
+
+			r := mux.NewRouter()
+			pc := NewPostController(mockLogger, mockPostSvc, config.HTTPCacheConfig{}, config.RequestsConfig{}, nil)
+			rr := httptest.NewRecorder()
+			r.HandleFunc("/post/search", pc.SearchPosts).Methods("GET")
+			r.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expected.statusCode, rr.Code)
+			assert.Equal(t, tc.expected.body, rr.Body.String())
+			assert.Equal(t, tc.expected.total, rr.Header().Get("X-Total-Count"))
+		})
+	}
+}
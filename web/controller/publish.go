@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/PostService/model"
+	"github.com/gorilla/mux"
+)
+
+// PublishPost publishes draft
+// /post/{id}/publish:
+//     post:
+//       tags:
+//         - developers
+//       summary: publish draft
+//       operationId: publishPost
+//       description: |
+//         Draft dated in the future becomes scheduled and is published at its date
+//       parameters:
+//         - in: path
+//           name: id
+//           description: post identifier
+//           required: true
+//           schema:
+//             type: string
+//         - in: header
+//           name: If-Match
+//           description: ETag of the post version being published
+//           required: true
+//           schema:
+//             type: string
+//       responses:
+//         '200':
+//           description: post published
+//         '403':
+//           description: authenticated client may not change post of this author
+//         '404':
+//           description: post not found
+//         '409':
+//           description: post is not a draft
+//         '412':
+//           description: post was changed since ETag from If-Match was received
+//         '428':
+//           description: If-Match header is required
+//         '500':
+//           description: service error
+func (pc *PostController) PublishPost(w http.ResponseWriter, r *http.Request) {
+	pc.transit(w, r, pc.postSvc.PublishPost, "Post published successfully")
+}
+
+// UnpublishPost turns post back into draft
+// /post/{id}/unpublish:
+//     post:
+//       tags:
+//         - developers
+//       summary: unpublish post
+//       operationId: unpublishPost
+//       description: |
+//         Published or scheduled post becomes draft and is excluded from listings
+//       parameters:
+//         - in: path
+//           name: id
+//           description: post identifier
+//           required: true
+//           schema:
+//             type: string
+//         - in: header
+//           name: If-Match
+//           description: ETag of the post version being unpublished
+//           required: true
+//           schema:
+//             type: string
+//       responses:
+//         '200':
+//           description: post unpublished
+//         '403':
+//           description: authenticated client may not change post of this author
+//         '404':
+//           description: post not found
+//         '409':
+//           description: post is a draft already
+//         '412':
+//           description: post was changed since ETag from If-Match was received
+//         '428':
+//           description: If-Match header is required
+//         '500':
+//           description: service error
+func (pc *PostController) UnpublishPost(w http.ResponseWriter, r *http.Request) {
+	pc.transit(w, r, pc.postSvc.UnpublishPost, "Post unpublished successfully")
+}
+
+// transit changes status of post by service action and writes new post version
+func (pc *PostController) transit(w http.ResponseWriter, r *http.Request,
+	action func(id string, actor model.Viewer, version int64) (model.Post, error), message string) {
+	version, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	p, err := action(mux.Vars(r)["id"], viewer(r), version)
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", postETag(p.Revision))
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(message)); err != nil {
+		pc.log.Error(err.Error())
+		return
+	}
+}
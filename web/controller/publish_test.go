@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/internal/authz"
+	"github.com/PostService/internal/post"
+	"github.com/PostService/mocks"
+	"github.com/PostService/model"
+	"github.com/PostService/web/auth"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublish(t *testing.T) {
+	type (
+		payload struct {
+			mockPostSvc func(mock *mocks.MockService)
+			path        string
+			headers     map[string]string
+			principal   *auth.Principal
+		}
+		expected struct {
+			body       string
+			etag       string
+			statusCode int
+		}
+	)
+	var testCases = []struct {
+		name     string
+		payload  payload
+		expected expected
+	}{
+		{
+			name: "publish draft",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().PublishPost("1", model.Viewer{}, int64(2)).Return(model.Post{ID: "1", Revision: 3, Status: model.StatusPublished}, nil)
+				},
+				path:    "/post/1/publish",
+				headers: map[string]string{"If-Match": `"2"`},
+			},
+			expected: expected{
+				body:       "Post published successfully",
+				etag:       `"3"`,
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "unpublish draft",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().UnpublishPost("1", model.Viewer{}, post.VersionAny).
+						Return(model.Post{}, fmt.Errorf("%w: can not unpublish draft post", post.ErrInvalidTransition))
+				},
+				path:    "/post/1/unpublish",
+				headers: map[string]string{"If-Match": "*"},
+			},
+			expected: expected{
+				body:       "invalid post status transition: can not unpublish draft post\n",
+				statusCode: http.StatusConflict,
+			},
+		},
+		{
+			name: "publish by authenticated admin",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().PublishPost("1", model.Viewer{ID: "1", Name: "root", Roles: []string{authz.RoleAdmin}}, post.VersionAny).Return(model.Post{ID: "1", Revision: 4}, nil)
+				},
+				path:      "/post/1/publish",
+				headers:   map[string]string{"If-Match": "*"},
+				principal: &auth.Principal{Subject: "1", Name: "root", Roles: []string{authz.RoleAdmin}},
+			},
+			expected: expected{
+				body:       "Post published successfully",
+				etag:       `"4"`,
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "publish without If-Match",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().PublishPost("1", model.Viewer{}, post.VersionUnknown).Return(model.Post{}, post.ErrVersionRequired)
+				},
+				path: "/post/1/publish",
+			},
+			expected: expected{
+				body:       "post version is required\n",
+				statusCode: http.StatusPreconditionRequired,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, tc.payload.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tc.payload.headers {
+				req.Header.Set(k, v)
+			}
+			if tc.payload.principal != nil {
+				req = req.WithContext(auth.NewContext(req.Context(), *tc.payload.principal))
+			}
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockPostSvc := mocks.NewMockService(mockCtrl)
+			tc.payload.mockPostSvc(mockPostSvc)
+
+			r := mux.NewRouter()
+			pc := NewPostController(mocks.NewMockLogger(mockCtrl), mockPostSvc, config.HTTPCacheConfig{}, config.RequestsConfig{}, nil)
+			r.HandleFunc("/post/{id:[0-9]+}/publish", pc.PublishPost).Methods(http.MethodPost)
+			r.HandleFunc("/post/{id:[0-9]+}/unpublish", pc.UnpublishPost).Methods(http.MethodPost)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expected.statusCode, rr.Code)
+			assert.Equal(t, tc.expected.body, rr.Body.String())
+			assert.Equal(t, tc.expected.etag, rr.Header().Get("ETag"))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/PostService/model"
+)
+
+// parseQuery read posts filters, order and page from query parameters
+func parseQuery(qParams url.Values) (model.Query, error) {
+	q := model.Query{
+		Name:   qParams.Get("post_name"),
+		Author: qParams.Get("author"),
+		Tags:   qParams["tag"],
+		Sort:   qParams.Get("sort"),
+	}
+	// order=true of older clients asked for the newest posts first
+	if qParams.Get("order") == "true" {
+		switch q.Sort {
+		case "":
+			q.Sort = model.SortDateDesc
+		case model.SortDateDesc:
+		default:
+			return model.Query{}, fmt.Errorf("order=true can not be combined with sort %q", q.Sort)
+		}
+	}
+	switch mode := qParams.Get("tag_mode"); mode {
+	case "", "and":
+	case "or":
+		q.AnyTag = true
+	default:
+		return model.Query{}, fmt.Errorf("invalid tag_mode %q, must be and or or", mode)
+	}
+	for _, bound := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		v := qParams.Get(bound.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(model.DateFormat, v)
+		if err != nil {
+			return model.Query{}, fmt.Errorf("invalid %s %q, must be in dd.mm.yy format", bound.name, v)
+		}
+		*bound.t = t
+	}
+	if v := qParams.Get("include_unpublished"); v != "" {
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			return model.Query{}, fmt.Errorf("invalid include_unpublished %q, must be true or false", v)
+		}
+		q.Unpublished = include
+	}
+
+	var err error
+	if q.Offset, q.Limit, err = parsePage(qParams); err != nil {
+		return model.Query{}, err
+	}
+	return q, nil
+}
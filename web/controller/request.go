@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxBodyBytes bounds request bodies when limit is not configured, post of maximum size fits it
+const defaultMaxBodyBytes = 1 << 20
+
+var (
+	// errBodyTooLarge returned when request body exceeds configured limit
+	errBodyTooLarge = errors.New("request body too large")
+	// errUnsupportedMediaType returned when request body is not JSON
+	errUnsupportedMediaType = errors.New("unsupported media type")
+	// errMultipleValues returned when request body has something after JSON value
+	errMultipleValues = errors.New("request body must contain single JSON value")
+)
+
+// readJSON decodes request body holding single JSON value into v
+// Body must be sent as application/json and fit limit, fields unknown to v are rejected
+// unless they are allowed by configuration
+func (pc *PostController) readJSON(r *http.Request, v interface{}) error {
+	if err := checkJSONContentType(r.Header.Get("Content-Type")); err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, pc.maxBodyBytes+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > pc.maxBodyBytes {
+		return fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, pc.maxBodyBytes)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if !pc.allowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errMultipleValues
+	}
+	return nil
+}
+
+// checkJSONContentType accepts application/json and structured syntax suffix +json
+func checkJSONContentType(contentType string) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%w: %q, application/json is expected", errUnsupportedMediaType, contentType)
+	}
+	if mediaType != "application/json" && !(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")) {
+		return fmt.Errorf("%w: %q, application/json is expected", errUnsupportedMediaType, mediaType)
+	}
+	return nil
+}
+
+// writeRequestError writes error of reading request body
+func writeRequestError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errBodyTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, errUnsupportedMediaType):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/mocks"
+	"github.com/PostService/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertPostBody(t *testing.T) {
+	valid := `{"post_name":"name1","date":"01.01.20","author":"author1"}`
+	var testCases = []struct {
+		name        string
+		conf        config.RequestsConfig
+		contentType string
+		body        string
+		statusCode  int
+		response    string
+	}{
+		{
+			name:        "valid",
+			contentType: "application/json; charset=utf-8",
+			body:        valid,
+			statusCode:  http.StatusOK,
+			response:    "Information stored successfully",
+		},
+		{
+			name:        "json suffix",
+			contentType: "application/merge-patch+json",
+			body:        valid + "\n",
+			statusCode:  http.StatusOK,
+			response:    "Information stored successfully",
+		},
+		{
+			name:       "missing content type",
+			body:       valid,
+			statusCode: http.StatusUnsupportedMediaType,
+			response:   "unsupported media type: \"\", application/json is expected\n",
+		},
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        valid,
+			statusCode:  http.StatusUnsupportedMediaType,
+			response:    "unsupported media type: \"application/x-www-form-urlencoded\", application/json is expected\n",
+		},
+		{
+			name:        "too large",
+			conf:        config.RequestsConfig{MaxBodyBytes: 20},
+			contentType: "application/json",
+			body:        valid,
+			statusCode:  http.StatusRequestEntityTooLarge,
+			response:    "request body too large: limit is 20 bytes\n",
+		},
+		{
+			name:        "unknown field",
+			contentType: "application/json",
+			body:        `{"post_name":"name1","date":"01.01.20","author":"author1","title":"x"}`,
+			statusCode:  http.StatusBadRequest,
+			response:    "json: unknown field \"title\"\n",
+		},
+		{
+			name:        "unknown field allowed",
+			conf:        config.RequestsConfig{AllowUnknownFields: true},
+			contentType: "application/json",
+			body:        `{"post_name":"name1","date":"01.01.20","author":"author1","title":"x"}`,
+			statusCode:  http.StatusOK,
+			response:    "Information stored successfully",
+		},
+		{
+			name:        "multiple values",
+			contentType: "application/json",
+			body:        valid + valid,
+			statusCode:  http.StatusBadRequest,
+			response:    "request body must contain single JSON value\n",
+		},
+		{
+			name:        "trailing garbage",
+			contentType: "application/json",
+			body:        valid + " x",
+			statusCode:  http.StatusBadRequest,
+			response:    "request body must contain single JSON value\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockPostSvc := mocks.NewMockService(mockCtrl)
+			mockLogger := mocks.NewMockLogger(mockCtrl)
+			if tc.statusCode == http.StatusOK {
+				mockPostSvc.EXPECT().InsertPost(gomock.Any(), model.Viewer{}).Return(nil)
+			} else {
+				mockLogger.EXPECT().Error(gomock.Any())
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/post", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rr := httptest.NewRecorder()
+			pc := NewPostController(mockLogger, mockPostSvc, config.HTTPCacheConfig{}, tc.conf, nil)
+			pc.InsertPost(rr, req)
+			assert.Equal(t, tc.statusCode, rr.Code)
+			assert.Equal(t, tc.response, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"container/list"
+	"sync"
+)
+
+// defaultResponseMaxBytes is size of all cached responses when it is not configured
+const defaultResponseMaxBytes = 16 << 20
+
+// cachedResponse is encoded posts list response
+type cachedResponse struct {
+	key         string
+	total       int64
+	contentType string
+	coding      string
+	body        []byte
+}
+
+// responseCache keeps at most maxEntries responses of total size maxBytes,
+// the least recently used response is dropped first
+type responseCache struct {
+	maxEntries int
+	maxBytes   int64
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// newResponseCache return cache of maxEntries responses, nil if no responses are cached
+func newResponseCache(maxEntries int, maxBytes int64) *responseCache {
+	if maxEntries <= 0 {
+		return nil
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultResponseMaxBytes
+	}
+	return &responseCache{maxEntries: maxEntries, maxBytes: maxBytes, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *responseCache) get(key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cachedResponse), true
+}
+
+// add stores response, responses larger than the whole cache are not stored
+func (c *responseCache) add(res *cachedResponse) {
+	if int64(len(res.body)) > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[res.key]; ok {
+		c.remove(el)
+	}
+	c.entries[res.key] = c.order.PushFront(res)
+	c.size += int64(len(res.body))
+	for c.order.Len() > c.maxEntries || c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *responseCache) remove(el *list.Element) {
+	res := c.order.Remove(el).(*cachedResponse)
+	delete(c.entries, res.key)
+	c.size -= int64(len(res.body))
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/mocks"
+	"github.com/PostService/model"
+	"github.com/PostService/web/compress"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedPosts(t *testing.T) {
+	q := model.Query{Author: "author1", Limit: defaultPageLimit}
+	date := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	posts := []model.Post{{Name: "name1", Author: "author1", Body: strings.Repeat("body ", 100), Date: date}}
+	version := model.Version{Counter: 7, Modified: date}
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockPostSvc := mocks.NewMockService(mockCtrl)
+	compressor, err := compress.New(config.CompressionConfig{MinBytes: 100})
+	require.NoError(t, err)
+	pc := NewPostController(mocks.NewMockLogger(mockCtrl), mockPostSvc,
+		config.HTTPCacheConfig{ResponseEntries: 10}, config.RequestsConfig{}, compressor)
+	r := mux.NewRouter()
+	r.HandleFunc("/post/{author}", pc.GetPostsByAuthor).Methods("GET")
+	get := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/post/author1", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	mockPostSvc.EXPECT().Version(q).Return(version, nil).Times(3)
+	// list is read and encoded once per content coding
+	mockPostSvc.EXPECT().Find(q).Return(posts, int64(1), nil).Times(2)
+	var plain []byte
+	for i := 0; i < 2; i++ {
+		rr := get("")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.Empty(t, rr.Header().Get("Content-Encoding"))
+		assert.Contains(t, rr.Body.String(), `"post_name":"name1"`)
+		plain = rr.Body.Bytes()
+	}
+
+	rr := get("gzip")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+	gz, err := gzip.NewReader(bytes.NewReader(rr.Body.Bytes()))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, plain, body)
+
+	t.Run("list of new version is read again", func(t *testing.T) {
+		mockPostSvc.EXPECT().Version(q).Return(model.Version{Counter: 8, Modified: date}, nil)
+		mockPostSvc.EXPECT().Find(q).Return(nil, int64(0), nil)
+		rr := get("")
+		assert.Equal(t, "No posts found", rr.Body.String())
+		assert.Equal(t, "text/plain", rr.Header().Get("Content-Type"))
+	})
+}
+
+func TestResponseCache(t *testing.T) {
+	assert.Nil(t, newResponseCache(0, 100))
+
+	c := newResponseCache(3, 10)
+	c.add(&cachedResponse{key: "a", body: []byte("1234")})
+	c.add(&cachedResponse{key: "b", body: []byte("1234")})
+	_, ok := c.get("a")
+	assert.True(t, ok)
+	c.add(&cachedResponse{key: "c", body: []byte("1234")})
+	_, ok = c.get("b")
+	assert.False(t, ok, "least recently used response is dropped once size is exceeded")
+	c.add(&cachedResponse{key: "d", body: []byte("12345678901")})
+	_, ok = c.get("d")
+	assert.False(t, ok, "response larger than cache is not stored")
+	_, ok = c.get("c")
+	assert.True(t, ok)
+	assert.Equal(t, int64(8), c.size)
+}
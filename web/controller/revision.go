@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/PostService/web/encoder"
+	"github.com/gorilla/mux"
+)
+
+// GetRevisions return revisions of post
+// /post/{id}/revisions:
+//     get:
+//       tags:
+//         - developers
+//       summary: return stored revisions of post, the latest first
+//       operationId: getRevisions
+//       parameters:
+//         - in: path
+//           name: id
+//           description: post identifier
+//           required: true
+//           schema:
+//             type: string
+//       responses:
+//         '200':
+//           description: revisions
+//           content:
+//             application/json:
+//               schema:
+//                 type: array
+//                 items:
+//                   $ref: '#/components/schemas/Revision'
+//         '404':
+//           description: post not found
+//         '406':
+//           description: none of supported media types is acceptable
+//         '500':
+//           description: service error
+func (pc *PostController) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	if !encoder.Accepts(r.Header.Get("Accept"), encoder.MediaJSON) {
+		http.Error(w, encoder.ErrNotAcceptable.Error(), http.StatusNotAcceptable)
+		return
+	}
+	revs, err := pc.postSvc.GetRevisions(mux.Vars(r)["id"], viewer(r))
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+	pc.writeJSON(w, revs)
+}
+
+// GetRevision return single revision of post
+// /post/{id}/revisions/{rev}:
+//     get:
+//       tags:
+//         - developers
+//       summary: return revision of post by its number
+//       operationId: getRevision
+//       parameters:
+//         - in: path
+//           name: id
+//           description: post identifier
+//           required: true
+//           schema:
+//             type: string
+//         - in: path
+//           name: rev
+//           description: revision number
+//           required: true
+//           schema:
+//             type: integer
+//       responses:
+//         '200':
+//           description: revision
+//           content:
+//             application/json:
+//               schema:
+//                 $ref: '#/components/schemas/Revision'
+//         '400':
+//           description: bad input parameter
+//         '404':
+//           description: post or revision not found
+//         '406':
+//           description: none of supported media types is acceptable
+//         '500':
+//           description: service error
+func (pc *PostController) GetRevision(w http.ResponseWriter, r *http.Request) {
+	if !encoder.Accepts(r.Header.Get("Accept"), encoder.MediaJSON) {
+		http.Error(w, encoder.ErrNotAcceptable.Error(), http.StatusNotAcceptable)
+		return
+	}
+	vars := mux.Vars(r)
+	number, err := parseRevision(vars["rev"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rev, err := pc.postSvc.GetRevision(vars["id"], number, viewer(r))
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+	pc.writeJSON(w, &rev)
+}
+
+// DiffRevisions return fields changed between two revisions of post
+// /post/{id}/diff:
+//     get:
+//       tags:
+//         - developers
+//       summary: return field level difference between two revisions of post
+//       operationId: diffRevisions
+//       parameters:
+//         - in: path
+//           name: id
+//           description: post identifier
+//           required: true
+//           schema:
+//             type: string
+//         - in: query
+//           name: from
+//           description: number of the base revision
+//           required: true
+//           schema:
+//             type: integer
+//         - in: query
+//           name: to
+//           description: number of the compared revision
+//           required: true
+//           schema:
+//             type: integer
+//       responses:
+//         '200':
+//           description: changed fields with values in both revisions
+//           content:
+//             application/json:
+//               schema:
+//                 type: array
+//                 items:
+//                   $ref: '#/components/schemas/FieldChange'
+//         '400':
+//           description: bad input parameter
+//         '404':
+//           description: post or revision not found
+//         '406':
+//           description: none of supported media types is acceptable
+//         '500':
+//           description: service error
+func (pc *PostController) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	if !encoder.Accepts(r.Header.Get("Accept"), encoder.MediaJSON) {
+		http.Error(w, encoder.ErrNotAcceptable.Error(), http.StatusNotAcceptable)
+		return
+	}
+	qParams := r.URL.Query()
+	from, err := parseRevision(qParams.Get("from"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseRevision(qParams.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	changes, err := pc.postSvc.DiffRevisions(mux.Vars(r)["id"], from, to, viewer(r))
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+	pc.writeJSON(w, changes)
+}
+
+// parseRevision read revision number
+func parseRevision(v string) (int64, error) {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid revision %q", v)
+	}
+	return n, nil
+}
@@ -0,0 +1,333 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/internal/authz"
+	"github.com/PostService/internal/post"
+	"github.com/PostService/mocks"
+	"github.com/PostService/model"
+	"github.com/PostService/web/auth"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevisions(t *testing.T) {
+	type (
+		payload struct {
+			mockPostSvc func(mock *mocks.MockService)
+			mockLogger  func(mock *mocks.MockLogger)
+			method      string
+			path        string
+			body        string
+			headers     map[string]string
+			principal   *auth.Principal
+		}
+		expected struct {
+			body       string
+			etag       string
+			statusCode int
+		}
+	)
+	created := time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)
+	var testCases = []struct {
+		name     string
+		payload  payload
+		expected expected
+	}{
+		{
+			name: "update post",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+					p := model.Post{Name: "name2", Date: date, Author: " author1"}
+					updated := p
+					updated.ID, updated.Revision = "1", 4
+					mock.EXPECT().UpdatePost("1", p, model.Viewer{}, int64(3)).Return(updated, nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodPut,
+				path:       "/post/1",
+				body:       `{"post_name":"name2","date":"01.01.20","author":" author1"}`,
+				headers:    map[string]string{"If-Match": `"3"`},
+			},
+			expected: expected{
+				body:       "Information stored successfully",
+				etag:       `"4"`,
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "update changed post",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().UpdatePost("1", gomock.Any(), model.Viewer{}, int64(3)).
+						Return(model.Post{}, fmt.Errorf("%w: current revision is 4", post.ErrVersionMismatch))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodPut,
+				path:       "/post/1",
+				body:       `{"post_name":"name2","date":"01.01.20","author":"author1"}`,
+				headers:    map[string]string{"If-Match": `"3"`},
+			},
+			expected: expected{
+				body:       "post version does not match: current revision is 4\n",
+				statusCode: http.StatusPreconditionFailed,
+			},
+		},
+		{
+			name: "update post of other author",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().UpdatePost("1", gomock.Any(), model.Viewer{}, int64(3)).
+						Return(model.Post{}, fmt.Errorf("%w: alice may update own posts only", post.ErrForbidden))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodPut,
+				path:       "/post/1",
+				body:       `{"post_name":"name2","date":"01.01.20","author":"bob"}`,
+				headers:    map[string]string{"If-Match": `"3"`},
+			},
+			expected: expected{
+				body:       "forbidden: alice may update own posts only\n",
+				statusCode: http.StatusForbidden,
+			},
+		},
+		{
+			name: "update without If-Match",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().UpdatePost("1", gomock.Any(), model.Viewer{}, post.VersionUnknown).Return(model.Post{}, post.ErrVersionRequired)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodPut,
+				path:       "/post/1",
+				body:       `{"post_name":"name2","date":"01.01.20","author":"author1"}`,
+			},
+			expected: expected{
+				body:       "post version is required\n",
+				statusCode: http.StatusPreconditionRequired,
+			},
+		},
+		{
+			name: "update with weak If-Match",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {},
+				mockLogger:  func(mock *mocks.MockLogger) {},
+				method:      http.MethodPut,
+				path:        "/post/1",
+				body:        `{"post_name":"name2","date":"01.01.20","author":"author1"}`,
+				headers:     map[string]string{"If-Match": `W/"3"`},
+			},
+			expected: expected{
+				body:       "invalid If-Match \"W/\\\"3\\\"\"\n",
+				statusCode: http.StatusPreconditionFailed,
+			},
+		},
+		{
+			name: "update missing post",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().UpdatePost("2", gomock.Any(), model.Viewer{}, post.VersionAny).Return(model.Post{}, fmt.Errorf("%w: 2", post.ErrNotFound))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodPut,
+				path:       "/post/2",
+				body:       `{"post_name":"name2","date":"01.01.20","author":"author1"}`,
+				headers:    map[string]string{"If-Match": "*"},
+			},
+			expected: expected{
+				body:       "post not found: 2\n",
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "update with invalid date",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {},
+				mockLogger: func(mock *mocks.MockLogger) {
+					mock.EXPECT().Error(`parsing time "2020-01-01" as "02.01.06": cannot parse "20-01-01" as "."`)
+				},
+				method: http.MethodPut,
+				path:   "/post/1",
+				body:   `{"post_name":"name2","date":"2020-01-01","author":"author1"}`,
+			},
+			expected: expected{
+				body:       "parsing time \"2020-01-01\" as \"02.01.06\": cannot parse \"20-01-01\" as \".\"\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "get revisions",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					revs := []model.Revision{{Number: 2, Editor: "author1", Created: created, Changes: []string{"post_name"},
+						Post: model.Post{ID: "1", Name: "name2", Author: "author1", Revision: 2}}}
+					mock.EXPECT().GetRevisions("1", model.Viewer{}).Return(revs, nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodGet,
+				path:       "/post/1/revisions",
+			},
+			expected: expected{
+				body: `[{"revision":2,"editor":"author1","created_at":"2021-02-01T10:00:00Z","changes":["post_name"],` +
+					`"post":{"id":"1","post_name":"name2","author":"author1","revision":2,"date":"01.01.01"}}]`,
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "get revisions error",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetRevisions("1", model.Viewer{}).Return(nil, errors.New("custom error"))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+					mock.EXPECT().Error("custom error")
+				},
+				method: http.MethodGet,
+				path:   "/post/1/revisions",
+			},
+			expected: expected{
+				body:       "custom error\n",
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+		{
+			name: "get revisions of draft anonymously",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetRevisions("1", model.Viewer{}).Return(nil, fmt.Errorf("%w: 1", post.ErrNotFound))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodGet,
+				path:       "/post/1/revisions",
+			},
+			expected: expected{
+				body:       "post not found: 1\n",
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "get revision of own draft",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					rev := model.Revision{Number: 1, Editor: "author1", Created: created,
+						Post: model.Post{ID: "1", Name: "name1", Author: "author1", Status: model.StatusDraft, Revision: 1}}
+					mock.EXPECT().GetRevision("1", int64(1), model.Viewer{ID: "1", Name: "author1", Roles: []string{authz.RoleWriter}}).Return(rev, nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodGet,
+				path:       "/post/1/revisions/1",
+				principal:  &auth.Principal{Subject: "1", Name: "author1", Roles: []string{authz.RoleWriter}},
+			},
+			expected: expected{
+				body: `{"revision":1,"editor":"author1","created_at":"2021-02-01T10:00:00Z",` +
+					`"post":{"id":"1","post_name":"name1","author":"author1","status":"draft","revision":1,"date":"01.01.01"}}`,
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "diff revisions of draft anonymously",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().DiffRevisions("1", int64(1), int64(2), model.Viewer{}).Return(nil, fmt.Errorf("%w: 1", post.ErrNotFound))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodGet,
+				path:       "/post/1/diff?from=1&to=2",
+			},
+			expected: expected{
+				body:       "post not found: 1\n",
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "get missing revision",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetRevision("1", int64(3), model.Viewer{}).Return(model.Revision{}, fmt.Errorf("%w: revision 3 of 1", post.ErrNotFound))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodGet,
+				path:       "/post/1/revisions/3",
+			},
+			expected: expected{
+				body:       "post not found: revision 3 of 1\n",
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "diff revisions",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					changes := []model.FieldChange{{Field: "post_name", From: "name1", To: "name2"}}
+					mock.EXPECT().DiffRevisions("1", int64(1), int64(2), model.Viewer{}).Return(changes, nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodGet,
+				path:       "/post/1/diff?from=1&to=2",
+			},
+			expected: expected{
+				body:       `[{"field":"post_name","from":"name1","to":"name2"}]`,
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "diff without base revision",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {},
+				mockLogger:  func(mock *mocks.MockLogger) {},
+				method:      http.MethodGet,
+				path:        "/post/1/diff?to=2",
+			},
+			expected: expected{
+				body:       "invalid revision \"\"\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.payload.method, tc.payload.path, strings.NewReader(tc.payload.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.payload.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			for k, v := range tc.payload.headers {
+				req.Header.Set(k, v)
+			}
+			if tc.payload.principal != nil {
+				req = req.WithContext(auth.NewContext(req.Context(), *tc.payload.principal))
+			}
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockPostSvc := mocks.NewMockService(mockCtrl)
+			tc.payload.mockPostSvc(mockPostSvc)
+			mockLogger := mocks.NewMockLogger(mockCtrl)
+			tc.payload.mockLogger(mockLogger)
+
+			r := mux.NewRouter()
+			pc := NewPostController(mockLogger, mockPostSvc, config.HTTPCacheConfig{}, config.RequestsConfig{}, nil)
+			rr := httptest.NewRecorder()
+			r.HandleFunc("/post/{id:[0-9]+}", pc.UpdatePost).Methods(http.MethodPut)
+			r.HandleFunc("/post/{id:[0-9]+}/revisions", pc.GetRevisions).Methods(http.MethodGet)
+			r.HandleFunc("/post/{id:[0-9]+}/revisions/{rev:[0-9]+}", pc.GetRevision).Methods(http.MethodGet)
+			r.HandleFunc("/post/{id:[0-9]+}/diff", pc.DiffRevisions).Methods(http.MethodGet)
+			r.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expected.statusCode, rr.Code)
+			assert.Equal(t, tc.expected.body, rr.Body.String())
+			assert.Equal(t, tc.expected.etag, rr.Header().Get("ETag"))
+		})
+	}
+}
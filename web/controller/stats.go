@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/PostService/model"
+	"github.com/PostService/web/encoder"
+)
+
+const defaultTopAuthors = 10
+
+// GetStats return aggregated statistic of posts
+// /stats:
+//     get:
+//       tags:
+//         - developers
+//       summary: return amount of posts grouped by time buckets or top authors
+//       operationId: getStats
+//       description: |
+//         Time grouping returns amount of posts in every bucket of the window
+//         (last 30 days, 12 weeks or 12 months by default), author grouping returns
+//         authors with the biggest amount of posts in the window (whole lifetime by default)
+//       parameters:
+//         - in: query
+//           name: group
+//           description: day, week, month or author, day by default
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
+//           name: from
+//           description: first day of the window in dd.mm.yy format
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
+//           name: to
+//           description: last day of the window in dd.mm.yy format
+//           required: false
+//           schema:
+//             type: string
+//         - in: query
+//           name: top
+//           description: amount of authors for author grouping
+//           required: false
+//           schema:
+//             type: integer
+//       responses:
+//         '200':
+//           description: statistic matching criteria
+//           content:
+//             application/json:
+//               schema:
+//                 type: array
+//                 items:
+//                   $ref: '#/components/schemas/Stat'
+//         '400':
+//           description: bad input parameter
+//         '406':
+//           description: none of supported media types is acceptable
+//         '500':
+//           description: service error
+func (pc *PostController) GetStats(w http.ResponseWriter, r *http.Request) {
+	if !encoder.Accepts(r.Header.Get("Accept"), encoder.MediaJSON) {
+		http.Error(w, encoder.ErrNotAcceptable.Error(), http.StatusNotAcceptable)
+		return
+	}
+	q, err := parseStatsQuery(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	stats, err := pc.postSvc.GetStats(q)
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+
+	pc.writeJSON(w, stats)
+}
+
+// parseStatsQuery read stats query parameters and fills default time window
+func parseStatsQuery(qParams url.Values, now time.Time) (model.StatsQuery, error) {
+	q := model.StatsQuery{Group: qParams.Get("group"), Top: defaultTopAuthors}
+	if q.Group == "" {
+		q.Group = model.GroupDay
+	}
+	var err error
+	if v := qParams.Get("from"); v != "" {
+		if q.From, err = time.Parse(model.DateFormat, v); err != nil {
+			return q, fmt.Errorf("invalid from %q", v)
+		}
+	}
+	if v := qParams.Get("to"); v != "" {
+		if q.To, err = time.Parse(model.DateFormat, v); err != nil {
+			return q, fmt.Errorf("invalid to %q", v)
+		}
+	}
+	if v := qParams.Get("top"); v != "" {
+		if q.Top, err = strconv.ParseInt(v, 10, 64); err != nil || q.Top <= 0 || q.Top > maxPageLimit {
+			return q, fmt.Errorf("invalid top %q, must be between 1 and %d", v, maxPageLimit)
+		}
+	}
+	if q.Group == model.GroupAuthor {
+		return q, nil
+	}
+
+	if q.To.IsZero() {
+		q.To = now.UTC()
+	}
+	if q.From.IsZero() {
+		switch q.Group {
+		case model.GroupWeek:
+			q.From = q.To.AddDate(0, 0, -7*11)
+		case model.GroupMonth:
+			q.From = q.To.AddDate(0, -11, 1-q.To.Day())
+		default:
+			q.From = q.To.AddDate(0, 0, -29)
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/internal/post"
+	"github.com/PostService/mocks"
+	"github.com/PostService/model"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatsQuery(t *testing.T) {
+	now := time.Date(2020, 3, 15, 10, 0, 0, 0, time.UTC)
+	var testCases = []struct {
+		name     string
+		qParams  url.Values
+		expected model.StatsQuery
+		err      string
+	}{
+		{
+			name:    "default day window",
+			qParams: url.Values{},
+			expected: model.StatsQuery{Group: model.GroupDay, Top: defaultTopAuthors,
+				From: time.Date(2020, 2, 15, 10, 0, 0, 0, time.UTC), To: now},
+		},
+		{
+			name:    "default month window",
+			qParams: url.Values{"group": {"month"}},
+			expected: model.StatsQuery{Group: model.GroupMonth, Top: defaultTopAuthors,
+				From: time.Date(2019, 4, 1, 10, 0, 0, 0, time.UTC), To: now},
+		},
+		{
+			name:     "author without window",
+			qParams:  url.Values{"group": {"author"}, "top": {"3"}},
+			expected: model.StatsQuery{Group: model.GroupAuthor, Top: 3},
+		},
+		{
+			name:    "explicit window",
+			qParams: url.Values{"from": {"01.01.20"}, "to": {"05.01.20"}},
+			expected: model.StatsQuery{Group: model.GroupDay, Top: defaultTopAuthors,
+				From: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:    "invalid date",
+			qParams: url.Values{"from": {"2020-01-01"}},
+			err:     `invalid from "2020-01-01"`,
+		},
+		{
+			name:    "invalid top",
+			qParams: url.Values{"group": {"author"}, "top": {"0"}},
+			err:     fmt.Sprintf(`invalid top "0", must be between 1 and %d`, maxPageLimit),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := parseStatsQuery(tc.qParams, now)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, q)
+		})
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	type (
+		payload struct {
+			mockPostSvc func(mock *mocks.MockService)
+			mockLogger  func(mock *mocks.MockLogger)
+			qParams     map[string]string
+		}
+		expected struct {
+			body       string
+			statusCode int
+		}
+	)
+	var testCases = []struct {
+		name     string
+		payload  payload
+		expected expected
+	}{
+		{
+			name: "invalid query",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetStats(gomock.Any()).Return(nil, fmt.Errorf("%w: unknown grouping", post.ErrInvalidQuery))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				qParams:    map[string]string{"group": "year"},
+			},
+			expected: expected{
+				body:       "invalid query: unknown grouping\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "service error",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetStats(gomock.Any()).Return(nil, errors.New("custom error"))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+					mock.EXPECT().Error("custom error")
+				},
+				qParams: map[string]string{"group": "author"},
+			},
+			expected: expected{
+				body:       "custom error\n",
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+		{
+			name: "success",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					q := model.StatsQuery{Group: model.GroupAuthor, Top: 2}
+					mock.EXPECT().GetStats(q).Return([]model.Stat{{Key: "Alice", Posts: 4}, {Key: "Bob", Posts: 1}}, nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				qParams:    map[string]string{"group": "author", "top": "2"},
+			},
+			expected: expected{
+				body:       `[{"key":"Alice","posts":4},{"key":"Bob","posts":1}]`,
+				statusCode: http.StatusOK,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", setParams("/stats", tc.payload.qParams), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockPostSvc := mocks.NewMockService(mockCtrl)
+			tc.payload.mockPostSvc(mockPostSvc)
+			mockLogger := mocks.NewMockLogger(mockCtrl)
+			tc.payload.mockLogger(mockLogger)
+
+			r := mux.NewRouter()
+			pc := NewPostController(mockLogger, mockPostSvc, config.HTTPCacheConfig{}, config.RequestsConfig{}, nil)
+			rr := httptest.NewRecorder()
+			r.HandleFunc("/stats", pc.GetStats).Methods("GET")
+			r.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expected.statusCode, rr.Code)
+			assert.Equal(t, tc.expected.body, rr.Body.String())
+		})
+	}
+}
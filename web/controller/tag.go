@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/PostService/web/encoder"
+)
+
+// GetTags return tags used by posts
+// /tags:
+//     get:
+//       tags:
+//         - developers
+//       summary: return list of tags ordered by amount of posts
+//       operationId: getTags
+//       description: |
+//         Tags with amount of posts marked by them, total amount is in X-Total-Count header
+//       parameters:
+//         - in: query
+//           name: offset
+//           description: amount of tags to skip
+//           required: false
+//           schema:
+//             type: integer
+//         - in: query
+//           name: limit
+//           description: maximum amount of tags
+//           required: false
+//           schema:
+//             type: integer
+//       responses:
+//         '200':
+//           description: tags
+//           content:
+//             application/json:
+//               schema:
+//                 type: array
+//                 items:
+//                   $ref: '#/components/schemas/Tag'
+//         '400':
+//           description: bad input parameter
+//         '406':
+//           description: none of supported media types is acceptable
+//         '500':
+//           description: service error
+func (pc *PostController) GetTags(w http.ResponseWriter, r *http.Request) {
+	if !encoder.Accepts(r.Header.Get("Accept"), encoder.MediaJSON) {
+		http.Error(w, encoder.ErrNotAcceptable.Error(), http.StatusNotAcceptable)
+		return
+	}
+	offset, limit, err := parsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tags, total, err := pc.postSvc.GetTags(offset, limit)
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	pc.writeJSON(w, tags)
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PostService/infrastructure/breaker"
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/mocks"
+	"github.com/PostService/model"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTags(t *testing.T) {
+	type (
+		payload struct {
+			mockPostSvc func(mock *mocks.MockService)
+			mockLogger  func(mock *mocks.MockLogger)
+			qParams     map[string]string
+		}
+		expected struct {
+			body       string
+			total      string
+			retryAfter string
+			statusCode int
+		}
+	)
+	var testCases = []struct {
+		name     string
+		payload  payload
+		expected expected
+	}{
+		{
+			name: "invalid offset",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {},
+				mockLogger:  func(mock *mocks.MockLogger) {},
+				qParams:     map[string]string{"offset": "-1"},
+			},
+			expected: expected{
+				body:       "invalid offset \"-1\"\n",
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "get tags error",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetTags(int64(0), int64(defaultPageLimit)).Return(nil, int64(0), errors.New("custom error"))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+					mock.EXPECT().Error("custom error")
+				},
+				qParams: map[string]string{"offset": "0"},
+			},
+			expected: expected{
+				body:       "custom error\n",
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+		{
+			name: "storage unavailable",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetTags(int64(0), int64(defaultPageLimit)).
+						Return(nil, int64(0), &breaker.OpenError{RetryAfter: 1500 * time.Millisecond})
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				qParams:    map[string]string{"offset": "0"},
+			},
+			expected: expected{
+				body:       "service is temporarily unavailable\n",
+				retryAfter: "2",
+				statusCode: http.StatusServiceUnavailable,
+			},
+		},
+		{
+			name: "success",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					tags := []model.Tag{{Name: "go", Posts: 2}}
+					mock.EXPECT().GetTags(int64(1), int64(1)).Return(tags, int64(3), nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				qParams:    map[string]string{"offset": "1", "limit": "1"},
+			},
+			expected: expected{
+				body:       `[{"tag":"go","posts":2}]`,
+				total:      "3",
+				statusCode: http.StatusOK,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", setParams("/tags", tc.payload.qParams), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockPostSvc := mocks.NewMockService(mockCtrl)
+			tc.payload.mockPostSvc(mockPostSvc)
+			mockLogger := mocks.NewMockLogger(mockCtrl)
+			tc.payload.mockLogger(mockLogger)
+
+			r := mux.NewRouter()
+			pc := NewPostController(mockLogger, mockPostSvc, config.HTTPCacheConfig{}, config.RequestsConfig{}, nil)
+			rr := httptest.NewRecorder()
+			r.HandleFunc("/tags", pc.GetTags).Methods("GET")
+			r.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expected.statusCode, rr.Code)
+			assert.Equal(t, tc.expected.body, rr.Body.String())
+			assert.Equal(t, tc.expected.total, rr.Header().Get("X-Total-Count"))
+			assert.Equal(t, tc.expected.retryAfter, rr.Header().Get("Retry-After"))
+		})
+	}
+}
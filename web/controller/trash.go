@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/PostService/web/encoder"
+	"github.com/gorilla/mux"
+)
+
+// DeletePost moves post to trash
+// /post/{id}:
+//     delete:
+//       tags:
+//         - developers
+//       summary: delete post
+//       operationId: deletePost
+//       description: |
+//         Deleted post is excluded from all listings and kept in trash
+//         until it is restored or purged after retention period
+//       parameters:
+//         - in: path
+//           name: id
+//           description: post identifier
+//           required: true
+//           schema:
+//             type: string
+//         - in: header
+//           name: If-Match
+//           description: ETag of the post version being deleted
+//           required: true
+//           schema:
+//             type: string
+//       responses:
+//         '204':
+//           description: post moved to trash
+//         '403':
+//           description: authenticated client may not change post of this author
+//         '404':
+//           description: post not found
+//         '412':
+//           description: post was changed since ETag from If-Match was received
+//         '428':
+//           description: If-Match header is required
+//         '500':
+//           description: service error
+func (pc *PostController) DeletePost(w http.ResponseWriter, r *http.Request) {
+	version, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err := pc.postSvc.DeletePost(mux.Vars(r)["id"], viewer(r), version); err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestorePost takes post out of trash
+// /post/{id}/restore:
+//     post:
+//       tags:
+//         - developers
+//       summary: restore deleted post
+//       operationId: restorePost
+//       parameters:
+//         - in: path
+//           name: id
+//           description: post identifier
+//           required: true
+//           schema:
+//             type: string
+//       responses:
+//         '200':
+//           description: post restored
+//         '403':
+//           description: authenticated client may not change post of this author
+//         '404':
+//           description: post not found in trash
+//         '500':
+//           description: service error
+func (pc *PostController) RestorePost(w http.ResponseWriter, r *http.Request) {
+	if err := pc.postSvc.RestorePost(mux.Vars(r)["id"], viewer(r)); err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(`Post restored successfully`)); err != nil {
+		pc.log.Error(err.Error())
+		return
+	}
+}
+
+// GetTrash return deleted posts
+// /trash:
+//     get:
+//       tags:
+//         - developers
+//       summary: return list of deleted posts, recently deleted first
+//       operationId: getTrash
+//       description: |
+//         Total amount of deleted posts is in X-Total-Count header. Authors see their own
+//         deleted posts only, admins see posts of all authors
+//       parameters:
+//         - in: query
+//           name: offset
+//           description: amount of posts to skip
+//           required: false
+//           schema:
+//             type: integer
+//         - in: query
+//           name: limit
+//           description: maximum amount of posts
+//           required: false
+//           schema:
+//             type: integer
+//       responses:
+//         '200':
+//           description: deleted posts
+//           content:
+//             application/json:
+//               schema:
+//                 type: array
+//                 items:
+//                   $ref: '#/components/schemas/Post'
+//         '400':
+//           description: bad input parameter
+//         '401':
+//           description: authentication required
+//         '403':
+//           description: client may not list deleted posts
+//         '406':
+//           description: none of supported media types is acceptable
+//         '500':
+//           description: service error
+func (pc *PostController) GetTrash(w http.ResponseWriter, r *http.Request) {
+	if !encoder.Accepts(r.Header.Get("Accept"), encoder.MediaJSON) {
+		http.Error(w, encoder.ErrNotAcceptable.Error(), http.StatusNotAcceptable)
+		return
+	}
+	offset, limit, err := parsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	posts, total, err := pc.postSvc.GetTrash(offset, limit, viewer(r))
+	if err != nil {
+		pc.writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	pc.writeJSON(w, posts)
+}
@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/internal/authz"
+	"github.com/PostService/internal/post"
+	"github.com/PostService/mocks"
+	"github.com/PostService/model"
+	"github.com/PostService/web/auth"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrash(t *testing.T) {
+	type (
+		payload struct {
+			mockPostSvc func(mock *mocks.MockService)
+			mockLogger  func(mock *mocks.MockLogger)
+			method      string
+			path        string
+			headers     map[string]string
+			principal   *auth.Principal
+		}
+		expected struct {
+			body       string
+			total      string
+			statusCode int
+		}
+	)
+	deletedAt := time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)
+	var testCases = []struct {
+		name     string
+		payload  payload
+		expected expected
+	}{
+		{
+			name: "delete post",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().DeletePost("1", model.Viewer{}, int64(2)).Return(nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodDelete,
+				path:       "/post/1",
+				headers:    map[string]string{"If-Match": `"2"`},
+			},
+			expected: expected{
+				statusCode: http.StatusNoContent,
+			},
+		},
+		{
+			name: "delete missing post",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().DeletePost("2", model.Viewer{}, post.VersionUnknown).Return(fmt.Errorf("%w: 2", post.ErrNotFound))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodDelete,
+				path:       "/post/2",
+			},
+			expected: expected{
+				body:       "post not found: 2\n",
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "delete error",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().DeletePost("1", model.Viewer{}, post.VersionUnknown).Return(errors.New("custom error"))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+					mock.EXPECT().Error("custom error")
+				},
+				method: http.MethodDelete,
+				path:   "/post/1",
+			},
+			expected: expected{
+				body:       "custom error\n",
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+		{
+			name: "restore post",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().RestorePost("1", model.Viewer{}).Return(nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodPost,
+				path:       "/post/1/restore",
+			},
+			expected: expected{
+				body:       "Post restored successfully",
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "restore post not in trash",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().RestorePost("1", model.Viewer{}).Return(fmt.Errorf("%w in trash: 1", post.ErrNotFound))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodPost,
+				path:       "/post/1/restore",
+			},
+			expected: expected{
+				body:       "post not found in trash: 1\n",
+				statusCode: http.StatusNotFound,
+			},
+		},
+		{
+			name: "get trash",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					date := time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC)
+					posts := []model.Post{{ID: "1", Name: "name1", Date: date, Author: "author1", DeletedAt: &deletedAt}}
+					mock.EXPECT().GetTrash(int64(0), int64(1), model.Viewer{}).Return(posts, int64(4), nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodGet,
+				path:       "/trash?limit=1",
+			},
+			expected: expected{
+				body:       `[{"id":"1","post_name":"name1","author":"author1","deleted_at":"2021-02-01T10:00:00Z","date":"01.01.20"}]`,
+				total:      "4",
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "get trash of writer",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetTrash(int64(0), int64(defaultPageLimit), model.Viewer{ID: "1", Name: "author1", Roles: []string{authz.RoleWriter}}).
+						Return([]model.Post{}, int64(0), nil)
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodGet,
+				path:       "/trash",
+				principal:  &auth.Principal{Subject: "1", Name: "author1", Roles: []string{authz.RoleWriter}},
+			},
+			expected: expected{
+				body:       `[]`,
+				total:      "0",
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "get trash forbidden",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetTrash(int64(0), int64(defaultPageLimit), model.Viewer{ID: "2", Name: "reader", Roles: []string{"reader"}}).
+						Return(nil, int64(0), fmt.Errorf("%w: reader may not delete posts", post.ErrForbidden))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {},
+				method:     http.MethodGet,
+				path:       "/trash",
+				principal:  &auth.Principal{Subject: "2", Name: "reader", Roles: []string{"reader"}},
+			},
+			expected: expected{
+				body:       "forbidden: reader may not delete posts\n",
+				statusCode: http.StatusForbidden,
+			},
+		},
+		{
+			name: "get trash error",
+			payload: payload{
+				mockPostSvc: func(mock *mocks.MockService) {
+					mock.EXPECT().GetTrash(int64(0), int64(defaultPageLimit), model.Viewer{}).Return(nil, int64(0), errors.New("custom error"))
+				},
+				mockLogger: func(mock *mocks.MockLogger) {
+					mock.EXPECT().Error("custom error")
+				},
+				method: http.MethodGet,
+				path:   "/trash",
+			},
+			expected: expected{
+				body:       "custom error\n",
+				statusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.payload.method, tc.payload.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tc.payload.headers {
+				req.Header.Set(k, v)
+			}
+			if tc.payload.principal != nil {
+				req = req.WithContext(auth.NewContext(req.Context(), *tc.payload.principal))
+			}
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			mockPostSvc := mocks.NewMockService(mockCtrl)
+			tc.payload.mockPostSvc(mockPostSvc)
+			mockLogger := mocks.NewMockLogger(mockCtrl)
+			tc.payload.mockLogger(mockLogger)
+
+			r := mux.NewRouter()
+			pc := NewPostController(mockLogger, mockPostSvc, config.HTTPCacheConfig{}, config.RequestsConfig{}, nil)
+			rr := httptest.NewRecorder()
+			r.HandleFunc("/post/{id:[0-9]+}", pc.DeletePost).Methods(http.MethodDelete)
+			r.HandleFunc("/post/{id:[0-9]+}/restore", pc.RestorePost).Methods(http.MethodPost)
+			r.HandleFunc("/trash", pc.GetTrash).Methods(http.MethodGet)
+			r.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expected.statusCode, rr.Code)
+			assert.Equal(t, tc.expected.body, rr.Body.String())
+			assert.Equal(t, tc.expected.total, rr.Header().Get("X-Total-Count"))
+		})
+	}
+}
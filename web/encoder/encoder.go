@@ -0,0 +1,337 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PostService/model"
+)
+
+// Media types supported by encoders
+const (
+	MediaJSON   = "application/json"
+	MediaCSV    = "text/csv"
+	MediaXML    = "application/xml"
+	MediaNDJSON = "application/x-ndjson"
+)
+
+// ErrNotAcceptable returned when none of supported media types satisfies Accept header
+var ErrNotAcceptable = errors.New("none of the supported media types is acceptable")
+
+// Encoder is interface for writing posts in particular media type
+// Fields limit written post fields, nil fields mean full representation
+type Encoder interface {
+	ContentType() string
+	Encode(w io.Writer, posts model.Posts, fields []string) error
+}
+
+// encoders holds supported encoders in order of server preference
+var encoders = []struct {
+	mediaType string
+	encoder   Encoder
+}{
+	{MediaJSON, jsonEncoder{}},
+	{MediaCSV, csvEncoder{}},
+	{MediaXML, xmlEncoder{}},
+	{MediaNDJSON, ndjsonEncoder{}},
+}
+
+// Negotiate return encoder which matches Accept header value best
+// Empty header is treated as */*, ties are resolved by server preference
+func Negotiate(accept string) (Encoder, error) {
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		return encoders[0].encoder, nil
+	}
+	var (
+		best        Encoder
+		bestQ       float64
+		bestPrecise int
+	)
+	for _, e := range encoders {
+		q, precise := quality(ranges, e.mediaType)
+		if q <= 0 {
+			continue
+		}
+		if best == nil || q > bestQ || (q == bestQ && precise > bestPrecise) {
+			best, bestQ, bestPrecise = e.encoder, q, precise
+		}
+	}
+	if best == nil {
+		return nil, ErrNotAcceptable
+	}
+	return best, nil
+}
+
+// Accepts reports whether media type is acceptable by Accept header value
+func Accepts(accept, mediaType string) bool {
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		return true
+	}
+	q, _ := quality(ranges, mediaType)
+	return q > 0
+}
+
+// mediaRange is single entry of Accept header
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mt := strings.ToLower(strings.TrimSpace(params[0]))
+		if mt == "" {
+			continue
+		}
+		slash := strings.Index(mt, "/")
+		if slash < 0 {
+			continue
+		}
+		mr := mediaRange{typ: mt[:slash], subtype: mt[slash+1:], q: 1}
+		for _, p := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+			if len(kv) == 2 && strings.ToLower(kv[0]) == "q" {
+				if q, err := strconv.ParseFloat(kv[1], 64); err == nil {
+					mr.q = q
+				}
+			}
+		}
+		ranges = append(ranges, mr)
+	}
+	// the most specific ranges must win, so they are checked first
+	sort.SliceStable(ranges, func(i, j int) bool { return specificity(ranges[i]) > specificity(ranges[j]) })
+	return ranges
+}
+
+func specificity(mr mediaRange) int {
+	switch {
+	case mr.typ == "*":
+		return 0
+	case mr.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// quality return q-value of the most specific range matching media type and its specificity
+func quality(ranges []mediaRange, mediaType string) (float64, int) {
+	slash := strings.Index(mediaType, "/")
+	typ, subtype := mediaType[:slash], mediaType[slash+1:]
+	for _, mr := range ranges {
+		if (mr.typ == "*" || mr.typ == typ) && (mr.subtype == "*" || mr.subtype == subtype) {
+			return mr.q, specificity(mr)
+		}
+	}
+	return 0, 0
+}
+
+// pair is name and value of post field in response
+type pair struct {
+	name  string
+	value interface{}
+}
+
+// project return requested fields of post, nil names mean full representation
+// in which empty optional fields are left out
+func project(p *model.Post, names []string) []pair {
+	fields := selectFields(names)
+	pairs := make([]pair, 0, len(fields))
+	for _, f := range fields {
+		v := f.value(p)
+		if names == nil && f.optional && isEmpty(v) {
+			continue
+		}
+		pairs = append(pairs, pair{name: f.name, value: v})
+	}
+	return pairs
+}
+
+// marshalProjection encodes requested fields of post as JSON object keeping fields order
+func marshalProjection(p *model.Post, names []string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, f := range project(p, names) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		v, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(strconv.Quote(f.name))
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string { return MediaJSON }
+
+func (jsonEncoder) Encode(w io.Writer, posts model.Posts, fields []string) error {
+	if fields == nil {
+		resp, err := json.Marshal(posts)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(resp)
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteByte('[')
+	for i := range posts {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		obj, err := marshalProjection(&posts[i], fields)
+		if err != nil {
+			return err
+		}
+		buf.Write(obj)
+	}
+	buf.WriteByte(']')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+type ndjsonEncoder struct{}
+
+func (ndjsonEncoder) ContentType() string { return MediaNDJSON }
+
+func (ndjsonEncoder) Encode(w io.Writer, posts model.Posts, fields []string) error {
+	enc := json.NewEncoder(w)
+	for i := range posts {
+		if fields == nil {
+			if err := enc.Encode(&posts[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		obj, err := marshalProjection(&posts[i], fields)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(obj, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type csvEncoder struct{}
+
+func (csvEncoder) ContentType() string { return MediaCSV + "; charset=utf-8" }
+
+// Encode writes csv with column per field, all fields are written by default
+func (csvEncoder) Encode(w io.Writer, posts model.Posts, fields []string) error {
+	columns := selectFields(fields)
+	cw := csv.NewWriter(w)
+	header := make([]string, 0, len(columns))
+	for _, c := range columns {
+		header = append(header, c.name)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for i := range posts {
+		row := make([]string, 0, len(columns))
+		for _, c := range columns {
+			row = append(row, flatten(c.value(&posts[i])))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type xmlEncoder struct{}
+
+type xmlTags struct {
+	Tags []string `xml:"tag"`
+}
+
+type xmlMetadata struct {
+	Entries []xmlEntry `xml:"entry"`
+}
+
+type xmlEntry struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (xmlEncoder) ContentType() string { return MediaXML }
+
+// Encode writes posts as <post> elements of <posts> root, id is written as attribute
+func (xmlEncoder) Encode(w io.Writer, posts model.Posts, fields []string) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	root := xml.StartElement{Name: xml.Name{Local: "posts"}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+	for i := range posts {
+		if err := encodeXMLPost(enc, &posts[i], fields); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func encodeXMLPost(enc *xml.Encoder, p *model.Post, fields []string) error {
+	start := xml.StartElement{Name: xml.Name{Local: "post"}}
+	elements := []pair{}
+	for _, f := range project(p, fields) {
+		if f.name == "id" {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: f.value.(string)})
+			continue
+		}
+		elements = append(elements, f)
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, f := range elements {
+		var v interface{} = f.value
+		switch val := f.value.(type) {
+		case []string:
+			v = xmlTags{Tags: val}
+		case map[string]string:
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			md := xmlMetadata{Entries: make([]xmlEntry, 0, len(keys))}
+			for _, k := range keys {
+				md.Entries = append(md.Entries, xmlEntry{Key: k, Value: val[k]})
+			}
+			v = md
+		}
+		if err := enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: f.name}}); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
@@ -0,0 +1,160 @@
+package encoder
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/PostService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		accept      string
+		contentType string
+		err         error
+	}{
+		{name: "empty header", accept: "", contentType: MediaJSON},
+		{name: "any media type", accept: "*/*", contentType: MediaJSON},
+		{name: "csv", accept: "text/csv", contentType: MediaCSV + "; charset=utf-8"},
+		{name: "xml with wildcard fallback", accept: "application/xml, */*;q=0.1", contentType: MediaXML},
+		{name: "ndjson preferred by quality", accept: "application/json;q=0.5, application/x-ndjson", contentType: MediaNDJSON},
+		{name: "type wildcard", accept: "text/*", contentType: MediaCSV + "; charset=utf-8"},
+		{name: "excluded by zero quality", accept: "application/json;q=0, text/plain", err: ErrNotAcceptable},
+		{name: "unsupported", accept: "image/png", err: ErrNotAcceptable},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			enc, err := Negotiate(tc.accept)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, tc.contentType, enc.ContentType())
+			}
+		})
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields("")
+	assert.NoError(t, err)
+	assert.Nil(t, fields)
+
+	fields, err = ParseFields("author, post_name,author")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"author", "post_name"}, fields)
+
+	_, err = ParseFields("post_name,secret")
+	assert.EqualError(t, err, `unknown field "secret"`)
+}
+
+func TestEncode(t *testing.T) {
+	posts := model.Posts{
+		{ID: "1", Name: "name1", Date: time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC), Author: "author1"},
+		{Name: "name2", Date: time.Date(2000, 1, 1, 1, 1, 1, 1, time.UTC), Author: "author2"},
+	}
+	var testCases = []struct {
+		name     string
+		encoder  Encoder
+		expected string
+	}{
+		{
+			name:     "json",
+			encoder:  jsonEncoder{},
+			expected: `[{"id":"1","post_name":"name1","author":"author1","date":"01.01.20"},{"post_name":"name2","author":"author2","date":"01.01.00"}]`,
+		},
+		{
+			name:     "ndjson",
+			encoder:  ndjsonEncoder{},
+			expected: "{\"id\":\"1\",\"post_name\":\"name1\",\"author\":\"author1\",\"date\":\"01.01.20\"}\n{\"post_name\":\"name2\",\"author\":\"author2\",\"date\":\"01.01.00\"}\n",
+		},
+		{
+			name:    "csv",
+			encoder: csvEncoder{},
+			expected: "id,post_name,date,author,summary,body,tags,language,metadata,status\n" +
+				"1,name1,01.01.20,author1,,,,,,\n,name2,01.01.00,author2,,,,,,\n",
+		},
+		{
+			name:    "xml",
+			encoder: xmlEncoder{},
+			expected: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<posts>" +
+				"<post id=\"1\"><post_name>name1</post_name><date>01.01.20</date><author>author1</author></post>" +
+				"<post><post_name>name2</post_name><date>01.01.00</date><author>author2</author></post></posts>",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			assert.NoError(t, tc.encoder.Encode(buf, posts, nil))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestEncodeRichPost(t *testing.T) {
+	posts := model.Posts{{
+		ID:       "1",
+		Name:     "name1",
+		Date:     time.Date(2020, 1, 1, 1, 1, 1, 1, time.UTC),
+		Author:   "author1",
+		Summary:  "short",
+		Body:     "# title",
+		Tags:     []string{"go", "redis"},
+		Language: "en",
+		Metadata: map[string]string{"source": "blog", "draft": "no"},
+	}}
+	var testCases = []struct {
+		name     string
+		encoder  Encoder
+		fields   []string
+		expected string
+	}{
+		{
+			name:    "json",
+			encoder: jsonEncoder{},
+			expected: `[{"id":"1","post_name":"name1","author":"author1","summary":"short","body":"# title",` +
+				`"tags":["go","redis"],"language":"en","metadata":{"draft":"no","source":"blog"},"date":"01.01.20"}]`,
+		},
+		{
+			name:     "sparse json",
+			encoder:  jsonEncoder{},
+			fields:   []string{"author", "tags", "date"},
+			expected: `[{"author":"author1","tags":["go","redis"],"date":"01.01.20"}]`,
+		},
+		{
+			name:     "sparse ndjson",
+			encoder:  ndjsonEncoder{},
+			fields:   []string{"post_name"},
+			expected: "{\"post_name\":\"name1\"}\n",
+		},
+		{
+			name:     "sparse csv",
+			encoder:  csvEncoder{},
+			fields:   []string{"post_name", "tags", "metadata"},
+			expected: "post_name,tags,metadata\nname1,go;redis,draft=no;source=blog\n",
+		},
+		{
+			name:    "xml",
+			encoder: xmlEncoder{},
+			expected: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<posts><post id=\"1\">" +
+				"<post_name>name1</post_name><date>01.01.20</date><author>author1</author>" +
+				"<summary>short</summary><body># title</body><tags><tag>go</tag><tag>redis</tag></tags>" +
+				"<language>en</language><metadata><entry key=\"draft\">no</entry><entry key=\"source\">blog</entry></metadata>" +
+				"</post></posts>",
+		},
+		{
+			name:     "sparse xml",
+			encoder:  xmlEncoder{},
+			fields:   []string{"post_name", "author"},
+			expected: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<posts><post><post_name>name1</post_name><author>author1</author></post></posts>",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			assert.NoError(t, tc.encoder.Encode(buf, posts, tc.fields))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package encoder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PostService/model"
+)
+
+// field is post field available in responses
+type field struct {
+	name string
+	// optional fields are left out of full representation when empty
+	optional bool
+	value    func(p *model.Post) interface{}
+}
+
+// postFields holds all response fields in default order
+var postFields = []field{
+	{name: "id", optional: true, value: func(p *model.Post) interface{} { return p.ID }},
+	{name: "post_name", value: func(p *model.Post) interface{} { return p.Name }},
+	{name: "date", value: func(p *model.Post) interface{} { return p.Date.Format(model.DateFormat) }},
+	{name: "author", value: func(p *model.Post) interface{} { return p.Author }},
+	{name: "summary", optional: true, value: func(p *model.Post) interface{} { return p.Summary }},
+	{name: "body", optional: true, value: func(p *model.Post) interface{} { return p.Body }},
+	{name: "tags", optional: true, value: func(p *model.Post) interface{} { return tagsValue(p.Tags) }},
+	{name: "language", optional: true, value: func(p *model.Post) interface{} { return p.Language }},
+	{name: "metadata", optional: true, value: func(p *model.Post) interface{} { return metadataValue(p.Metadata) }},
+	{name: "status", optional: true, value: func(p *model.Post) interface{} { return p.Status }},
+}
+
+// ParseFields parses comma separated list of requested post fields
+// Empty list means full representation and is returned as nil
+func ParseFields(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	fields := []string{}
+	seen := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := lookupField(name); !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			fields = append(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+func lookupField(name string) (field, bool) {
+	for _, f := range postFields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// selectFields return requested fields, nil names mean all fields
+func selectFields(names []string) []field {
+	if names == nil {
+		return postFields
+	}
+	fields := make([]field, 0, len(names))
+	for _, name := range names {
+		if f, ok := lookupField(name); ok {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// isEmpty reports whether field value holds nothing
+func isEmpty(v interface{}) bool {
+	switch val := v.(type) {
+	case string:
+		return val == ""
+	case []string:
+		return len(val) == 0
+	case map[string]string:
+		return len(val) == 0
+	}
+	return false
+}
+
+func tagsValue(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func metadataValue(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return map[string]string{}
+	}
+	return metadata
+}
+
+// flatten return text form of field value for flat formats
+// tags are joined by semicolon, metadata is written as sorted key=value pairs
+func flatten(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []string:
+		return strings.Join(val, ";")
+	case map[string]string:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, k+"="+val[k])
+		}
+		return strings.Join(pairs, ";")
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepSize is amount of buckets kept before restored buckets are dropped
+const sweepSize = 10000
+
+// bucket is token bucket of client, every request takes a token
+type bucket struct {
+	tokens float64
+	at     time.Time
+	// full is time when all tokens are restored
+	full time.Time
+}
+
+// memoryLimiter counts requests in process memory, so every replica limits clients on its own
+type memoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func newMemoryLimiter() *memoryLimiter {
+	return &memoryLimiter{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// Allow takes token from bucket of key
+func (m *memoryLimiter) Allow(key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if len(m.buckets) >= sweepSize {
+		m.sweep(now)
+	}
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), at: now}
+		m.buckets[key] = b
+	}
+	return take(b, limit, now), nil
+}
+
+// sweep drops buckets which are full again, they are the same as missing ones
+func (m *memoryLimiter) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// take refills bucket for time passed since it was used and takes token if there is one,
+// tokens are restored at rate of limit requests per window
+func take(b *bucket, limit Limit, now time.Time) Result {
+	capacity := float64(limit.Requests)
+	perToken := float64(limit.Window) / capacity
+	if elapsed := now.Sub(b.at); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+float64(elapsed)/perToken)
+		b.at = now
+	}
+
+	res := Result{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration(math.Ceil((1 - b.tokens) * perToken))
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration(math.Ceil((capacity - b.tokens) * perToken))
+	b.full = now.Add(res.Reset)
+	return res
+}
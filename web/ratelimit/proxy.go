@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/PostService/infrastructure/config"
+)
+
+// proxies tells IP of client from addresses of proxies request passed
+type proxies struct {
+	trustForwardedFor bool
+	// trusted are networks of proxies, peer of service is trusted when it is empty
+	trusted []*net.IPNet
+}
+
+// newProxies return proxies of configuration, trusted proxies are CIDRs or single IPs
+func newProxies(conf config.RateLimitConfig) (proxies, error) {
+	ps := proxies{trustForwardedFor: conf.TrustForwardedFor}
+	for _, proxy := range conf.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return proxies{}, fmt.Errorf("trusted proxy %q is not IP or CIDR", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			ps.trusted = append(ps.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return proxies{}, fmt.Errorf("trusted proxy %q is not IP or CIDR: %w", proxy, err)
+		}
+		ps.trusted = append(ps.trusted, network)
+	}
+	return ps, nil
+}
+
+// trusts reports whether address belongs to trusted proxy
+func (ps proxies) trusts(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range ps.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP return IP of client. Every proxy appends address of its peer to X-Forwarded-For,
+// so addresses are walked from the end and the first one not of trusted proxy is the client,
+// addresses left of it could be sent by client itself
+func (ps proxies) clientIP(r *http.Request) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+	if !ps.trustForwardedFor || (len(ps.trusted) > 0 && !ps.trusts(peer)) {
+		return peer
+	}
+	forwarded := r.Header.Values("X-Forwarded-For")
+	if len(forwarded) == 0 {
+		return peer
+	}
+	hops := strings.Split(strings.Join(forwarded, ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop != "" && !ps.trusts(hop) {
+			return hop
+		}
+	}
+	// request passed trusted proxies only
+	if hop := strings.TrimSpace(hops[0]); hop != "" {
+		return hop
+	}
+	return peer
+}
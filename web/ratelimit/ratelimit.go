@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/infrastructure/logger"
+	"github.com/PostService/web/auth"
+	"github.com/gorilla/mux"
+)
+
+// Limit is amount of requests allowed per window, spent requests are restored evenly over window
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Result is outcome of request counted against limit
+type Result struct {
+	Allowed bool
+	// Remaining is amount of requests client may still make right away
+	Remaining int
+	// Reset is time until all spent requests are restored
+	Reset time.Duration
+	// RetryAfter is time until next request is allowed when request is not allowed
+	RetryAfter time.Duration
+}
+
+// Limiter counts requests of clients
+type Limiter interface {
+	// Allow counts request by key against limit
+	Allow(key string, limit Limit) (Result, error)
+}
+
+// rules holds limits of routes
+type rules struct {
+	base   Limit
+	routes map[string]Limit
+}
+
+func newRules(conf config.RateLimitConfig) rules {
+	rs := rules{base: newLimit(conf.Requests, conf.WindowSeconds), routes: map[string]Limit{}}
+	for _, route := range conf.Routes {
+		rs.routes[normalizeRoute(route.Route)] = newLimit(route.Requests, route.WindowSeconds)
+	}
+	return rs
+}
+
+// newLimit return limit of requests per window seconds, window is one minute by default
+func newLimit(requests, windowSeconds int) Limit {
+	window := time.Duration(windowSeconds) * time.Second
+	if window <= 0 {
+		window = time.Minute
+	}
+	return Limit{Requests: requests, Window: window}
+}
+
+// limit return limit of route
+func (rs rules) limit(route string) Limit {
+	if l, ok := rs.routes[route]; ok {
+		return l
+	}
+	return rs.base
+}
+
+// normalizeRoute return route as upper case method and path template separated by single space
+func normalizeRoute(route string) string {
+	fields := strings.Fields(route)
+	if len(fields) != 2 {
+		return route
+	}
+	return strings.ToUpper(fields[0]) + " " + fields[1]
+}
+
+// Middleware answers requests of clients exceeding limit of route by 429, every response
+// carries RateLimit-* headers of limited route
+// It has to run after routing and authentication to tell routes and clients apart
+func Middleware(log logger.Logger, limiter Limiter, conf config.RateLimitConfig) (func(http.Handler) http.Handler, error) {
+	rs := newRules(conf)
+	ps, err := newProxies(conf)
+	if err != nil {
+		return nil, err
+	}
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeOf(r)
+			limit := rs.limit(route)
+			if limit.Requests <= 0 {
+				h.ServeHTTP(w, r)
+				return
+			}
+			if allow(log, w, limiter, route, route+"|"+client(r, ps), limit) {
+				h.ServeHTTP(w, r)
+			}
+		})
+	}, nil
+}
+
+// Authentication answers requests carrying credentials by 429 once their client IP exceeds limit
+// of authentication attempts, so credentials can not be guessed at the rate of route limits
+// It has to run before authentication, which answers rejected credentials by itself
+func Authentication(log logger.Logger, limiter Limiter, conf config.RateLimitConfig) (func(http.Handler) http.Handler, error) {
+	limit := newLimit(conf.AuthRequests, conf.AuthWindowSeconds)
+	ps, err := newProxies(conf)
+	if err != nil {
+		return nil, err
+	}
+	return func(h http.Handler) http.Handler {
+		if limit.Requests <= 0 {
+			return h
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !auth.HasCredentials(r) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			if allow(log, w, limiter, "authentication", "auth|ip:"+ps.clientIP(r), limit) {
+				h.ServeHTTP(w, r)
+			}
+		})
+	}, nil
+}
+
+// allow counts request by key against limit named name and writes RateLimit-* headers,
+// request exceeding limit is answered by 429 and false is returned
+func allow(log logger.Logger, w http.ResponseWriter, limiter Limiter, name, key string, limit Limit) bool {
+	res, err := limiter.Allow(key, limit)
+	if err != nil {
+		// requests are not rejected because limits can not be counted
+		log.Printf("rate limit of %s is not checked: %v", name, err)
+		return true
+	}
+
+	w.Header().Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(seconds(limit.Window)))
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+	if !res.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// routeOf return method and path template of matched route, path for requests routed elsewhere
+func routeOf(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return r.Method + " " + tpl
+		}
+	}
+	return r.Method + " " + r.URL.Path
+}
+
+// client return key of request client: authenticated principal or IP
+func client(r *http.Request, ps proxies) string {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return "principal:" + p.Subject
+	}
+	return "ip:" + ps.clientIP(r)
+}
+
+// seconds return duration rounded up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,261 @@
+package ratelimit
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PostService/infrastructure/breaker"
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/mocks"
+	"github.com/PostService/web/auth"
+	"github.com/go-redis/redis"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := newMemoryLimiter()
+	m.now = func() time.Time { return now }
+	limit := Limit{Requests: 2, Window: 10 * time.Second}
+
+	var testCases = []struct {
+		name    string
+		elapsed time.Duration
+		result  Result
+	}{
+		{name: "first request", result: Result{Allowed: true, Remaining: 1, Reset: 5 * time.Second}},
+		{name: "second request", result: Result{Allowed: true, Remaining: 0, Reset: 10 * time.Second}},
+		{name: "limit exceeded", elapsed: time.Second, result: Result{Remaining: 0, Reset: 9 * time.Second, RetryAfter: 4 * time.Second}},
+		{name: "token restored", elapsed: 4 * time.Second, result: Result{Allowed: true, Remaining: 0, Reset: 10 * time.Second}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now = now.Add(tc.elapsed)
+			res, err := m.Allow("client", limit)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.result, res)
+		})
+	}
+
+	res, err := m.Allow("other client", limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+}
+
+func TestRedisLimiterBreaker(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	log := mocks.NewMockLogger(mockCtrl)
+	log.EXPECT().Printf("rate limits are counted in memory until redis is available: %v", gomock.Any())
+
+	dials := 0
+	rc := redis.NewClient(&redis.Options{Dialer: func() (net.Conn, error) {
+		dials++
+		return nil, errors.New("redis is down")
+	}})
+	defer rc.Close()
+	b := breaker.New(1, time.Hour, 1, nil)
+	l := NewRedis(rc, b, func(error) bool { return true }, log)
+	limit := Limit{Requests: 1, Window: time.Minute}
+
+	// failure opens breaker, later requests are counted in memory without waiting for redis
+	res, err := l.Allow("client", limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, breaker.Open, b.State())
+	dialed := dials
+	res, err = l.Allow("client", limit)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, dialed, dials)
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(key string, limit Limit) (Result, error) {
+	return Result{}, errors.New("redis is down")
+}
+
+func TestMiddleware(t *testing.T) {
+	conf := config.RateLimitConfig{
+		Requests:      1,
+		WindowSeconds: 60,
+		Routes:        []config.RouteLimitConfig{{Route: "post /post", Requests: 2, WindowSeconds: 10}, {Route: "GET /tags"}},
+	}
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	log := mocks.NewMockLogger(mockCtrl)
+
+	newRouter := func(limiter Limiter) *mux.Router {
+		r := mux.NewRouter()
+		ok := func(w http.ResponseWriter, r *http.Request) {}
+		r.HandleFunc("/post", ok).Methods(http.MethodPost)
+		r.HandleFunc("/post/{author}", ok).Methods(http.MethodGet)
+		r.HandleFunc("/tags", ok).Methods(http.MethodGet)
+		limit, err := Middleware(log, limiter, conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Use(limit)
+		return r
+	}
+	r := newRouter(newMemoryLimiter())
+	serve := func(method, path, remoteAddr string, p *auth.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteAddr
+		if p != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), *p))
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("route limit", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			rr := serve(http.MethodPost, "/post", "10.0.0.1:1000", nil)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+			assert.Equal(t, "2;w=10", rr.Header().Get("RateLimit-Policy"))
+		}
+		rr := serve(http.MethodPost, "/post", "10.0.0.1:2000", nil)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "rate limit exceeded\n", rr.Body.String())
+		assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "10", rr.Header().Get("RateLimit-Reset"))
+		assert.Equal(t, "5", rr.Header().Get("Retry-After"))
+	})
+	t.Run("clients are limited separately", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/post", "10.0.0.2:1000", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		rr = serve(http.MethodPost, "/post", "10.0.0.1:1000", &auth.Principal{Subject: "alice"})
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+	t.Run("routes are limited by template", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/post/alice", "10.0.0.1:1000", nil).Code)
+		rr := serve(http.MethodGet, "/post/bob", "10.0.0.1:1000", nil)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+	})
+	t.Run("unlimited route", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			rr := serve(http.MethodGet, "/tags", "10.0.0.1:1000", nil)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+		}
+	})
+	t.Run("limiter error", func(t *testing.T) {
+		log.EXPECT().Printf("rate limit of %s is not checked: %v", "GET /post/{author}", gomock.Any())
+		r = newRouter(failingLimiter{})
+		rr := serve(http.MethodGet, "/post/alice", "10.0.0.1:1000", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
+
+func TestAuthentication(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	log := mocks.NewMockLogger(mockCtrl)
+	log.EXPECT().Printf("authentication of %s %s failed: %v", http.MethodGet, "/post", gomock.Any()).Times(2)
+
+	authenticators, err := auth.New(config.AuthConfig{APIKeys: []config.APIKeyConfig{{Key: "secret", Name: "alice"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	conf := config.RateLimitConfig{AuthRequests: 2, AuthWindowSeconds: 10}
+	limit, err := Authentication(log, newMemoryLimiter(), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := limit(auth.Middleware(log, authenticators)(ok))
+	serve := func(remoteAddr, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/post", nil)
+		req.RemoteAddr = remoteAddr
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// rejected credentials are counted before authentication answers them
+	assert.Equal(t, http.StatusUnauthorized, serve("10.0.0.1:1000", "guess1").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("10.0.0.1:1000", "guess2").Code)
+	rr := serve("10.0.0.1:1000", "secret")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "5", rr.Header().Get("Retry-After"))
+
+	// requests without credentials and other clients are not limited
+	assert.Equal(t, http.StatusOK, serve("10.0.0.1:1000", "").Code)
+	assert.Equal(t, http.StatusOK, serve("10.0.0.2:1000", "secret").Code)
+}
+
+func TestClientIP(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		conf       config.RateLimitConfig
+		remoteAddr string
+		forwarded  string
+		ip         string
+	}{
+		{name: "forwarded for is not trusted", remoteAddr: "10.0.0.1:1000", forwarded: "192.168.0.1", ip: "10.0.0.1"},
+		{name: "peer is trusted", conf: config.RateLimitConfig{TrustForwardedFor: true},
+			remoteAddr: "10.0.0.1:1000", forwarded: "192.168.0.1, 10.0.0.5", ip: "10.0.0.5"},
+		{name: "trusted proxies are skipped", conf: config.RateLimitConfig{TrustForwardedFor: true, TrustedProxies: []string{"10.0.0.0/8"}},
+			remoteAddr: "10.0.0.1:1000", forwarded: "192.168.0.1, 10.0.0.5", ip: "192.168.0.1"},
+		{name: "trusted proxy IP", conf: config.RateLimitConfig{TrustForwardedFor: true, TrustedProxies: []string{"10.0.0.1", "10.0.0.5"}},
+			remoteAddr: "10.0.0.1:1000", forwarded: "192.168.0.1, 10.0.0.5", ip: "192.168.0.1"},
+		{name: "untrusted peer", conf: config.RateLimitConfig{TrustForwardedFor: true, TrustedProxies: []string{"10.0.0.0/8"}},
+			remoteAddr: "172.16.0.1:1000", forwarded: "192.168.0.1", ip: "172.16.0.1"},
+		{name: "only trusted proxies", conf: config.RateLimitConfig{TrustForwardedFor: true, TrustedProxies: []string{"10.0.0.0/8"}},
+			remoteAddr: "10.0.0.1:1000", forwarded: "10.0.0.7, 10.0.0.5", ip: "10.0.0.7"},
+		{name: "without forwarded for", conf: config.RateLimitConfig{TrustForwardedFor: true},
+			remoteAddr: "10.0.0.1:1000", ip: "10.0.0.1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ps, err := newProxies(tc.conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := httptest.NewRequest(http.MethodGet, "/post", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+			assert.Equal(t, tc.ip, ps.clientIP(r))
+		})
+	}
+}
+
+func TestSpoofedForwardedFor(t *testing.T) {
+	ps, err := newProxies(config.RateLimitConfig{TrustForwardedFor: true, TrustedProxies: []string{"10.0.0.0/8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := func(forwarded string) string {
+		r := httptest.NewRequest(http.MethodGet, "/post", nil)
+		r.RemoteAddr = "10.0.0.1:1000"
+		r.Header.Set("X-Forwarded-For", forwarded)
+		return client(r, ps)
+	}
+	// proxy appends the real client, addresses before it are sent by client
+	assert.Equal(t, "ip:192.168.0.1", key("192.168.0.1, 10.0.0.5"))
+	assert.Equal(t, "ip:192.168.0.1", key("1.2.3.4, 192.168.0.1, 10.0.0.5"))
+	assert.Equal(t, "ip:192.168.0.1", key("5.6.7.8, 192.168.0.1, 10.0.0.5"))
+}
+
+func TestInvalidTrustedProxy(t *testing.T) {
+	_, err := Middleware(nil, newMemoryLimiter(), config.RateLimitConfig{TrustedProxies: []string{"10.0.0.0/33"}})
+	assert.Error(t, err)
+	_, err = Authentication(nil, newMemoryLimiter(), config.RateLimitConfig{TrustedProxies: []string{"proxy"}})
+	assert.Error(t, err)
+}
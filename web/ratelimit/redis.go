@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/PostService/infrastructure/breaker"
+	"github.com/PostService/infrastructure/logger"
+	"github.com/go-redis/redis"
+)
+
+// keyPrefix is prefix of redis keys of token buckets
+const keyPrefix = "ratelimit:"
+
+// takeScript is token bucket shared by replicas, it refills bucket for time passed since
+// it was used and takes token if there is one
+// KEYS[1] bucket hash; ARGV[1] requests, ARGV[2] window ms, ARGV[3] now ms
+// Returns allowed flag, remaining requests, ms until bucket is full and ms until next token
+var takeScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local per_token = window / capacity
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(state[1])
+local at = tonumber(state[2])
+if tokens == nil or at == nil then
+  tokens = capacity
+  at = now
+end
+if now > at then
+  tokens = math.min(capacity, tokens + (now - at) / per_token)
+  at = now
+end
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) * per_token)
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'at', at)
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, math.floor(tokens), math.ceil((capacity - tokens) * per_token), retry}
+`)
+
+// errUnexpectedReply returned when script reply is not the one it returns
+var errUnexpectedReply = errors.New("unexpected reply of rate limit script")
+
+// redisLimiter counts requests in redis, so limits are shared by replicas
+// While redis is not available requests are counted in memory
+type redisLimiter struct {
+	rc       redis.UniversalClient
+	b        *breaker.Breaker
+	failed   func(error) bool
+	log      logger.Logger
+	fallback *memoryLimiter
+	degraded int32
+	now      func() time.Time
+}
+
+// NewRedis return limiter keeping counters in redis with in memory fallback. Redis is called
+// through breaker b unless it is nil, failed tells errors of unavailable redis, so requests are
+// counted in memory right away while breaker is open
+func NewRedis(rc redis.UniversalClient, b *breaker.Breaker, failed func(error) bool, log logger.Logger) Limiter {
+	return &redisLimiter{rc: rc, b: b, failed: failed, log: log, fallback: newMemoryLimiter(), now: time.Now}
+}
+
+// take runs token bucket script of key, through breaker unless it is nil
+func (l *redisLimiter) take(key string, limit Limit) (interface{}, error) {
+	var resp interface{}
+	run := func() (err error) {
+		resp, err = takeScript.Run(l.rc, []string{keyPrefix + key},
+			limit.Requests, limit.Window.Milliseconds(), l.now().UnixNano()/int64(time.Millisecond)).Result()
+		return err
+	}
+	var err error
+	if l.b == nil {
+		err = run()
+	} else {
+		err = l.b.Call(run, l.failed)
+	}
+	return resp, err
+}
+
+// Allow takes token from bucket of key
+func (l *redisLimiter) Allow(key string, limit Limit) (Result, error) {
+	resp, err := l.take(key, limit)
+	if err != nil {
+		if atomic.CompareAndSwapInt32(&l.degraded, 0, 1) {
+			l.log.Printf("rate limits are counted in memory until redis is available: %v", err)
+		}
+		return l.fallback.Allow(key, limit)
+	}
+	if atomic.CompareAndSwapInt32(&l.degraded, 1, 0) {
+		l.log.Printf("rate limits are counted in redis again")
+	}
+
+	values, ok := resp.([]interface{})
+	if !ok || len(values) != 4 {
+		return Result{}, errUnexpectedReply
+	}
+	ints := make([]int64, len(values))
+	for i, v := range values {
+		if ints[i], ok = v.(int64); !ok {
+			return Result{}, errUnexpectedReply
+		}
+	}
+	return Result{
+		Allowed:    ints[0] == 1,
+		Remaining:  int(ints[1]),
+		Reset:      time.Duration(ints[2]) * time.Millisecond,
+		RetryAfter: time.Duration(ints[3]) * time.Millisecond,
+	}, nil
+}
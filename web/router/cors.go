@@ -0,0 +1,118 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/gorilla/handlers"
+)
+
+var (
+	// defaultCORSMethods are methods of service routes
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}
+	// defaultCORSHeaders are request headers read by service
+	defaultCORSHeaders = []string{"Content-Type", "Authorization", "X-API-Key", "If-Match", "If-None-Match", "If-Modified-Since"}
+	// exposedCORSHeaders are response headers set by service which browsers hide from scripts
+	exposedCORSHeaders = []string{"ETag", "Last-Modified", "X-Total-Count", "Retry-After",
+		"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"}
+)
+
+// CORS return middleware applying cross-origin resource sharing policy of environment
+func CORS(conf config.CORSConfig, environment string) (func(http.Handler) http.Handler, error) {
+	origins := conf.AllowedOrigins
+	if envOrigins, ok := conf.Environments[environment]; ok {
+		origins = envOrigins
+	}
+	matchers := make([]originMatcher, 0, len(origins))
+	for _, origin := range origins {
+		if origin == "*" && conf.AllowCredentials {
+			return nil, errors.New("CORS origin * can not be allowed with credentials")
+		}
+		m, err := newOriginMatcher(origin)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+
+	opts := []handlers.CORSOption{
+		handlers.AllowedMethods(orDefault(conf.AllowedMethods, defaultCORSMethods)),
+		handlers.AllowedHeaders(orDefault(conf.AllowedHeaders, defaultCORSHeaders)),
+		handlers.ExposedHeaders(orDefault(conf.ExposedHeaders, exposedCORSHeaders)),
+		handlers.MaxAge(conf.MaxAgeSeconds),
+	}
+	if conf.AllowCredentials {
+		opts = append(opts, handlers.AllowCredentials())
+	}
+	if len(origins) == 1 && origins[0] == "*" {
+		opts = append(opts, handlers.AllowedOrigins(origins))
+		return handlers.CORS(opts...), nil
+	}
+	opts = append(opts, handlers.AllowedOriginValidator(func(origin string) bool {
+		origin = strings.ToLower(origin)
+		for _, m := range matchers {
+			if m.match(origin) {
+				return true
+			}
+		}
+		return false
+	}))
+	cors := handlers.CORS(opts...)
+	return func(h http.Handler) http.Handler {
+		corsHandler := cors(h)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// allowed origin is echoed, so responses differ by origin
+			w.Header().Add("Vary", "Origin")
+			corsHandler.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// originMatcher matches origin exactly or by wildcard subdomains when pattern has prefix
+type originMatcher struct {
+	prefix   string
+	suffix   string
+	wildcard bool
+}
+
+// newOriginMatcher parses origin or pattern like https://*.example.com
+func newOriginMatcher(pattern string) (originMatcher, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	parts := strings.Split(pattern, "*")
+	switch {
+	case pattern == "*":
+		return originMatcher{wildcard: true}, nil
+	case len(parts) == 1:
+		return originMatcher{prefix: pattern}, nil
+	case len(parts) == 2 && strings.HasSuffix(parts[0], "://") && strings.HasPrefix(parts[1], "."):
+		return originMatcher{prefix: parts[0], suffix: parts[1], wildcard: true}, nil
+	}
+	return originMatcher{}, fmt.Errorf("invalid CORS origin pattern %q", pattern)
+}
+
+// match reports whether lower cased origin matches, wildcard stands for one or more host labels
+func (m originMatcher) match(origin string) bool {
+	if !m.wildcard {
+		return origin == m.prefix
+	}
+	if !strings.HasPrefix(origin, m.prefix) || !strings.HasSuffix(origin, m.suffix) ||
+		len(origin) <= len(m.prefix)+len(m.suffix) {
+		return false
+	}
+	for _, c := range origin[len(m.prefix) : len(origin)-len(m.suffix)] {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func orDefault(values, defaults []string) []string {
+	if len(values) == 0 {
+		return defaults
+	}
+	return values
+}
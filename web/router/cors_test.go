@@ -0,0 +1,66 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	conf := config.CORSConfig{
+		AllowedOrigins: []string{"*"},
+		Environments: map[string][]string{
+			"production": {"https://example.com", "https://*.example.org"},
+		},
+		AllowCredentials: false,
+		MaxAgeSeconds:    600,
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	var testCases = []struct {
+		name        string
+		environment string
+		method      string
+		origin      string
+		allowOrigin string
+	}{
+		{name: "any origin", environment: "development", method: http.MethodGet, origin: "https://evil.com", allowOrigin: "*"},
+		{name: "exact origin", environment: "production", method: http.MethodGet, origin: "https://Example.com", allowOrigin: "https://Example.com"},
+		{name: "subdomain", environment: "production", method: http.MethodGet, origin: "https://a.b.example.org", allowOrigin: "https://a.b.example.org"},
+		{name: "domain of pattern", environment: "production", method: http.MethodGet, origin: "https://example.org"},
+		{name: "suffix of pattern", environment: "production", method: http.MethodGet, origin: "https://evil.com?.example.org"},
+		{name: "other origin", environment: "production", method: http.MethodGet, origin: "https://example.com.evil.com"},
+		{name: "preflight", environment: "production", method: http.MethodOptions, origin: "https://example.com", allowOrigin: "https://example.com"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cors, err := CORS(conf, tc.environment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(tc.method, "/post", nil)
+			req.Header.Set("Origin", tc.origin)
+			if tc.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+				req.Header.Set("Access-Control-Request-Headers", "If-Match, Content-Type")
+			}
+			rr := httptest.NewRecorder()
+			cors(ok).ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tc.allowOrigin, rr.Header().Get("Access-Control-Allow-Origin"))
+			if tc.method == http.MethodOptions {
+				assert.Equal(t, "If-Match,Content-Type", rr.Header().Get("Access-Control-Allow-Headers"))
+				assert.Equal(t, "600", rr.Header().Get("Access-Control-Max-Age"))
+			} else if tc.allowOrigin != "" {
+				assert.Contains(t, rr.Header().Get("Access-Control-Expose-Headers"), "Last-Modified")
+			}
+		})
+	}
+
+	_, err := CORS(config.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}, "")
+	assert.EqualError(t, err, "CORS origin * can not be allowed with credentials")
+	_, err = CORS(config.CORSConfig{AllowedOrigins: []string{"https://*example.com"}}, "")
+	assert.EqualError(t, err, `invalid CORS origin pattern "https://*example.com"`)
+}
@@ -3,31 +3,57 @@ package router
 import (
 	"net/http"
 
+	"github.com/PostService/infrastructure/config"
 	"github.com/PostService/infrastructure/logger"
 	"github.com/PostService/internal/post"
-	postCache "github.com/PostService/internal/post/cache"
+	"github.com/PostService/web/auth"
+	"github.com/PostService/web/compress"
 	"github.com/PostService/web/controller"
-	"github.com/go-redis/redis"
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 )
 
-// New base router
-func New(log logger.Logger, rc *redis.Client) (router *mux.Router,
-	headers handlers.CORSOption,
-	methods handlers.CORSOption,
-	origins handlers.CORSOption,
-	err error) {
-	router = mux.NewRouter().StrictSlash(true)
+// New base router serving posts of postSvc
+func New(log logger.Logger, postSvc post.Service, conf config.Configuration) (*mux.Router, error) {
+	router := mux.NewRouter().StrictSlash(true)
+	authenticators, err := auth.New(conf.Auth)
+	if err != nil {
+		return nil, err
+	}
+	router.Use(auth.Middleware(log, authenticators))
+	// write routes and trash are open only while authentication is not configured
+	write := func(h http.HandlerFunc) http.Handler {
+		if len(authenticators) == 0 {
+			return h
+		}
+		return auth.Require(h)
+	}
 
-	postSvc := post.NewPostService(postCache.NewPostCache(rc))
-	postCntr := controller.NewPostController(log, postSvc)
-	router.HandleFunc("/post", postCntr.InsertPost).Methods(http.MethodPost)
+	// compressed posts lists are cached by controller, so it compresses them by the same settings
+	var compressor *compress.Compressor
+	if conf.Compression.Enabled {
+		if compressor, err = compress.New(conf.Compression); err != nil {
+			return nil, err
+		}
+		router.Use(compressor.Middleware)
+	}
+
+	postCntr := controller.NewPostController(log, postSvc, conf.HTTPCache, conf.Requests, compressor)
+	router.Handle("/post", write(postCntr.InsertPost)).Methods(http.MethodPost)
 	router.HandleFunc("/post", postCntr.GetPosts).Methods(http.MethodGet)
+	router.HandleFunc("/post/search", postCntr.SearchPosts).Methods(http.MethodGet)
+	router.HandleFunc("/post/{id:[0-9]+}", postCntr.GetPost).Methods(http.MethodGet)
 	router.HandleFunc("/post/{author}", postCntr.GetPostsByAuthor).Methods(http.MethodGet)
-
-	headers = handlers.AllowedHeaders([]string{"Content-Type", "Authorization"})
-	methods = handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE"})
-	origins = handlers.AllowedOrigins([]string{"*"})
-	return router, headers, methods, origins, nil
+	router.Handle("/post/{id:[0-9]+}", write(postCntr.UpdatePost)).Methods(http.MethodPut)
+	router.Handle("/post/{id:[0-9]+}", write(postCntr.DeletePost)).Methods(http.MethodDelete)
+	router.HandleFunc("/post/{id:[0-9]+}/revisions", postCntr.GetRevisions).Methods(http.MethodGet)
+	router.HandleFunc("/post/{id:[0-9]+}/revisions/{rev:[0-9]+}", postCntr.GetRevision).Methods(http.MethodGet)
+	router.HandleFunc("/post/{id:[0-9]+}/diff", postCntr.DiffRevisions).Methods(http.MethodGet)
+	router.Handle("/post/{id:[0-9]+}/restore", write(postCntr.RestorePost)).Methods(http.MethodPost)
+	router.Handle("/post/{id:[0-9]+}/publish", write(postCntr.PublishPost)).Methods(http.MethodPost)
+	router.Handle("/post/{id:[0-9]+}/unpublish", write(postCntr.UnpublishPost)).Methods(http.MethodPost)
+	router.Handle("/trash", write(postCntr.GetTrash)).Methods(http.MethodGet)
+	router.HandleFunc("/authors", postCntr.GetAuthors).Methods(http.MethodGet)
+	router.HandleFunc("/stats", postCntr.GetStats).Methods(http.MethodGet)
+	router.HandleFunc("/tags", postCntr.GetTags).Methods(http.MethodGet)
+	return router, nil
 }
@@ -0,0 +1,40 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PostService/infrastructure/config"
+	"github.com/PostService/mocks"
+	"github.com/PostService/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrashRequiresAuthentication(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockPostSvc := mocks.NewMockService(mockCtrl)
+	writer := model.Viewer{ID: "apikey:author1", Name: "author1", Roles: []string{"writer"}}
+	mockPostSvc.EXPECT().GetTrash(int64(0), gomock.Any(), writer).Return([]model.Post{}, int64(0), nil)
+	conf := config.Configuration{Auth: config.AuthConfig{
+		APIKeys: []config.APIKeyConfig{{Key: "secret", Name: "author1", Roles: []string{"writer"}}},
+	}}
+	r, err := New(mocks.NewMockLogger(mockCtrl), mockPostSvc, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/trash", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/trash", nil)
+	req.Header.Set("X-API-Key", "secret")
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "0", rr.Header().Get("X-Total-Count"))
+}